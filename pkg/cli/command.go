@@ -23,7 +23,10 @@ func NewRootCommand(ctx context.Context, use, long string) *cobra.Command {
 	return cmd
 }
 
-type GroupCommandOptions struct{}
+type GroupCommandOptions struct {
+	// Related commands (full command paths) and links, see SetCommandSeeAlso.
+	SeeAlso []string
+}
 
 func NewGroupCommand(ctx context.Context, use, short, long string, group *CommandGroup, options GroupCommandOptions) *cobra.Command {
 	cmd := &cobra.Command{
@@ -40,12 +43,20 @@ func NewGroupCommand(ctx context.Context, use, short, long string, group *Comman
 		},
 	}
 
+	if len(options.SeeAlso) > 0 {
+		SetCommandSeeAlso(cmd, options.SeeAlso...)
+	}
+
 	return cmd
 }
 
 type SubCommandOptions struct {
 	Args              cobra.PositionalArgs
 	ValidArgsFunction func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective)
+	// Usage examples, see SetCommandExamples.
+	Examples []CommandExample
+	// Related commands (full command paths) and links, see SetCommandSeeAlso.
+	SeeAlso []string
 }
 
 func NewSubCommand(ctx context.Context, use, short, long string, priority int, group *CommandGroup, options SubCommandOptions, runE func(cmd *cobra.Command, args []string) error) *cobra.Command {
@@ -69,14 +80,24 @@ func NewSubCommand(ctx context.Context, use, short, long string, priority int, g
 
 	SetSubCommandAnnotations(cmd, priority, group)
 
+	if len(options.Examples) > 0 {
+		SetCommandExamples(cmd, options.Examples...)
+	}
+
+	if len(options.SeeAlso) > 0 {
+		SetCommandSeeAlso(cmd, options.SeeAlso...)
+	}
+
 	return cmd
 }
 
 func SetSubCommandAnnotations(cmd *cobra.Command, priority int, group *CommandGroup) {
-	cmd.Annotations = map[string]string{
-		CommandGroupIDAnnotationName:       group.ID,
-		CommandGroupTitleAnnotationName:    group.Title,
-		CommandGroupPriorityAnnotationName: fmt.Sprintf("%d", group.Priority),
-		CommandPriorityAnnotationName:      fmt.Sprintf("%d", priority),
+	if cmd.Annotations == nil {
+		cmd.Annotations = map[string]string{}
 	}
+
+	cmd.Annotations[CommandGroupIDAnnotationName] = group.ID
+	cmd.Annotations[CommandGroupTitleAnnotationName] = group.Title
+	cmd.Annotations[CommandGroupPriorityAnnotationName] = fmt.Sprintf("%d", group.Priority)
+	cmd.Annotations[CommandPriorityAnnotationName] = fmt.Sprintf("%d", priority)
 }
@@ -0,0 +1,111 @@
+package cli
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	// Command examples, JSON-encoded []CommandExample.
+	CommandExamplesAnnotationName = "examples"
+	// Related commands and links, JSON-encoded []string.
+	CommandSeeAlsoAnnotationName = "see-also"
+)
+
+// CommandExample is a usage example of a command, shown in the help and in the reference docs.
+type CommandExample struct {
+	// What the example does, e.g. "Deploy to the staging cluster".
+	Description string `json:"description,omitempty"`
+	// Command line, e.g. "nelm release install --kube-context staging". Might be multiline.
+	Command string `json:"command"`
+}
+
+// SetCommandExamples saves the examples in the command annotations. The cobra Example field is
+// set too, so that the examples are shown by the default cobra help template.
+func SetCommandExamples(cmd *cobra.Command, examples ...CommandExample) {
+	setCommandAnnotationJSON(cmd, CommandExamplesAnnotationName, examples)
+	cmd.Example = formatCommandExamples(examples, 0)
+}
+
+// GetCommandExamples returns the examples saved with SetCommandExamples.
+func GetCommandExamples(cmd *cobra.Command) []CommandExample {
+	var examples []CommandExample
+	getCommandAnnotationJSON(cmd, CommandExamplesAnnotationName, &examples)
+
+	return examples
+}
+
+// SetCommandSeeAlso saves related commands (full command paths, e.g. "nelm release list") and
+// links (URLs) in the command annotations.
+func SetCommandSeeAlso(cmd *cobra.Command, seeAlso ...string) {
+	setCommandAnnotationJSON(cmd, CommandSeeAlsoAnnotationName, seeAlso)
+}
+
+// GetCommandSeeAlso returns the related commands and links saved with SetCommandSeeAlso.
+func GetCommandSeeAlso(cmd *cobra.Command) []string {
+	var seeAlso []string
+	getCommandAnnotationJSON(cmd, CommandSeeAlsoAnnotationName, &seeAlso)
+
+	return seeAlso
+}
+
+// Format examples as an indented shell snippet with descriptions as comments. Descriptions are
+// wrapped to the width, if not zero.
+func formatCommandExamples(examples []CommandExample, width int) string {
+	var blocks []string
+	for _, example := range examples {
+		var lines []string
+		if description := strings.TrimSpace(example.Description); description != "" {
+			if width > 0 {
+				description = wrapText(description, width-len("  # "))
+			}
+
+			lines = append(lines, "# "+strings.ReplaceAll(description, "\n", "\n# "))
+		}
+		lines = append(lines, strings.TrimSpace(example.Command))
+
+		blocks = append(blocks, "  "+strings.ReplaceAll(strings.Join(lines, "\n"), "\n", "\n  "))
+	}
+
+	return strings.Join(blocks, "\n\n")
+}
+
+func isURL(s string) bool {
+	return strings.HasPrefix(s, "http://") || strings.HasPrefix(s, "https://")
+}
+
+// Find the command by its full path in the command tree.
+func findCommandByPath(cmd *cobra.Command, path string) *cobra.Command {
+	root := cmd.Root()
+
+	fields := strings.Fields(path)
+	if len(fields) == 0 || fields[0] != root.Name() {
+		return nil
+	}
+
+	found, _, err := root.Find(fields[1:])
+	if err != nil || found.CommandPath() != strings.Join(fields, " ") {
+		return nil
+	}
+
+	return found
+}
+
+func setCommandAnnotationJSON(cmd *cobra.Command, name string, value any) {
+	if cmd.Annotations == nil {
+		cmd.Annotations = map[string]string{}
+	}
+
+	// Only slices of strings and structs of strings are saved, which are always marshaled.
+	data, _ := json.Marshal(value)
+	cmd.Annotations[name] = string(data)
+}
+
+// Invalid annotations are ignored, since they are only set with setCommandAnnotationJSON.
+func getCommandAnnotationJSON(cmd *cobra.Command, name string, dest any) {
+	if data, ok := cmd.Annotations[name]; ok {
+		_ = json.Unmarshal([]byte(data), dest)
+	}
+}
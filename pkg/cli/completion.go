@@ -0,0 +1,99 @@
+package cli
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/werf/common-go/pkg/util"
+)
+
+// CompletionFunc completes flag values or positional args, see cobra.Command.ValidArgsFunction.
+type CompletionFunc func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective)
+
+// CompletionValue is a completion candidate with an optional description shown by the shells
+// supporting it.
+type CompletionValue struct {
+	Value       string
+	Description string
+}
+
+// CompleteFilesWithExtensions completes files with the extensions, e.g. "yaml" or ".yml", and
+// directories.
+func CompleteFilesWithExtensions(extensions ...string) CompletionFunc {
+	exts := make([]string, 0, len(extensions))
+	for _, ext := range extensions {
+		exts = append(exts, strings.TrimPrefix(ext, "."))
+	}
+
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return exts, cobra.ShellCompDirectiveFilterFileExt
+	}
+}
+
+// CompleteDirs completes directories relative to the base directory or, if the base directory is
+// empty, to the current directory.
+func CompleteDirs(baseDir string) CompletionFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if baseDir == "" {
+			return nil, cobra.ShellCompDirectiveFilterDirs
+		}
+
+		return []string{baseDir}, cobra.ShellCompDirectiveFilterDirs
+	}
+}
+
+// CompleteValues completes the values with the prefix being completed. Values are returned by
+// the callback, which is called on each completion request, e.g. to list releases in a cluster.
+// If the callback fails, the completion fails too.
+func CompleteValues(valuesFunc func(cmd *cobra.Command, args []string, toComplete string) ([]CompletionValue, error)) CompletionFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		values, err := valuesFunc(cmd, args, toComplete)
+		if err != nil {
+			cobra.CompErrorln(fmt.Sprintf("get completion values: %s", err))
+			return nil, cobra.ShellCompDirectiveError
+		}
+
+		var result []string
+		for _, value := range values {
+			if !strings.HasPrefix(value.Value, toComplete) {
+				continue
+			}
+
+			if value.Description == "" {
+				result = append(result, value.Value)
+			} else {
+				result = append(result, value.Value+"\t"+value.Description)
+			}
+		}
+
+		return result, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// CompleteStaticValues is like CompleteValues, but for the values known in advance.
+func CompleteStaticValues(values ...CompletionValue) CompletionFunc {
+	return CompleteValues(func(cmd *cobra.Command, args []string, toComplete string) ([]CompletionValue, error) {
+		return values, nil
+	})
+}
+
+// CompleteEnvVarNames completes names of env vars from the provider, which have the prefix, e.g.
+// for flags taking the name of an env var to read a secret from.
+func CompleteEnvVarNames(provider util.EnvProvider, prefix string) CompletionFunc {
+	return CompleteValues(func(cmd *cobra.Command, args []string, toComplete string) ([]CompletionValue, error) {
+		var values []CompletionValue
+		for _, keyValue := range provider.Environ() {
+			name, _, _ := strings.Cut(keyValue, "=")
+			if strings.HasPrefix(name, prefix) {
+				values = append(values, CompletionValue{Value: name})
+			}
+		}
+
+		sort.Slice(values, func(i, j int) bool { return values[i].Value < values[j].Value })
+
+		return values, nil
+	})
+}
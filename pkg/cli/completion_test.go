@@ -0,0 +1,59 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/cobra"
+
+	"github.com/werf/common-go/pkg/util"
+)
+
+func complete(cmd *cobra.Command, args ...string) string {
+	var out bytes.Buffer
+	cmd.Root().SetOut(&out)
+	cmd.Root().SetErr(&bytes.Buffer{})
+	cmd.Root().SetArgs(append([]string{cobra.ShellCompRequestCmd}, args...))
+	Expect(cmd.Root().Execute()).To(Succeed())
+
+	return out.String()
+}
+
+var _ = Describe("completion", func() {
+	It("should complete flags added with AddFlag", func() {
+		cmd := newTestCommand()
+		registry := newTestRegistry(nil)
+
+		var values, strategy, kubeContext, release, secretEnv, chartDir string
+		Expect(AddFlag(cmd, &values, "values", "", "", AddFlagOptions{Registry: registry, CompletionFunc: CompleteFilesWithExtensions(".yaml", "yml")})).To(Succeed())
+		Expect(AddFlag(cmd, &chartDir, "chart-dir", "", "", AddFlagOptions{Registry: registry, CompletionFunc: CompleteDirs("charts")})).To(Succeed())
+		Expect(AddFlag(cmd, &strategy, "strategy", "", "", AddFlagOptions{Registry: registry, AllowedValues: []string{"rolling", "recreate"}})).To(Succeed())
+		Expect(AddFlag(cmd, &kubeContext, "kube-context", "", "", AddFlagOptions{
+			Registry:      registry,
+			AllowedValues: []string{"dev", "prod"},
+			CompletionFunc: CompleteStaticValues(
+				CompletionValue{Value: "dev", Description: "Development cluster"},
+				CompletionValue{Value: "prod", Description: "Production cluster"},
+			),
+		})).To(Succeed())
+		Expect(AddFlag(cmd, &release, "release", "", "", AddFlagOptions{
+			Registry: registry,
+			CompletionFunc: CompleteValues(func(cmd *cobra.Command, args []string, toComplete string) ([]CompletionValue, error) {
+				return nil, errors.New("cluster is unreachable")
+			}),
+		})).To(Succeed())
+		Expect(AddFlag(cmd, &secretEnv, "secret-env", "", "", AddFlagOptions{
+			Registry:       registry,
+			CompletionFunc: CompleteEnvVarNames(util.MapEnvProvider{"APP_TOKEN": "1", "APP_PASSWORD": "2", "HOME": "/root"}, "APP_"),
+		})).To(Succeed())
+
+		Expect(complete(cmd, "run", "--values", "")).To(Equal("yaml\nyml\n:8\n"))
+		Expect(complete(cmd, "run", "--chart-dir", "")).To(Equal("charts\n:16\n"))
+		Expect(complete(cmd, "run", "--strategy", "re")).To(Equal("recreate\n:4\n"))
+		Expect(complete(cmd, "run", "--kube-context", "")).To(Equal("dev\tDevelopment cluster\nprod\tProduction cluster\n:4\n"))
+		Expect(complete(cmd, "run", "--release", "")).To(Equal(":1\n"))
+		Expect(complete(cmd, "run", "--secret-env", "APP_P")).To(Equal("APP_PASSWORD\n:4\n"))
+	})
+})
@@ -0,0 +1,300 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	yaml_v3 "gopkg.in/yaml.v3"
+)
+
+type ConfigFileOptions struct {
+	// Don't fail on keys which are neither flags nor command sections.
+	AllowUnknownKeys bool
+}
+
+// ApplyConfigFile reads flag values from the YAML or JSON config file and assigns them to the
+// flags of the command, which are not set by env vars or cli args. Values priority (from lowest
+// to highest): flag default value -> config file value -> environment variable value -> cli flag
+// value. Does nothing if the path is empty.
+//
+// Top-level keys of the config file are flag names. A key matching a subcommand name is a section
+// with flags (and nested sections) for that subcommand, which override the values from the
+// enclosing sections:
+//
+//	kube-context: production
+//	deploy:
+//	  timeout: 10m
+//	  values: [values.yaml, values-production.yaml]
+//	release:
+//	  list:
+//	    output: json
+//
+// Top-level flags are applied to any command having such a flag. Slice flags accept lists, map
+// flags accept maps. Intended to be called in PersistentPreRunE of the root command with the path
+// taken from a flag, which itself can be set via an env var. Applied values mark the flags as
+// changed, and cobra validates required flags after PersistentPreRunE, so required flags can be
+// set in the config file.
+func ApplyConfigFile(cmd *cobra.Command, path string, opts ConfigFileOptions) error {
+	if path == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read config file %q: %w", path, err)
+	}
+
+	if err := ApplyConfig(cmd, data, opts); err != nil {
+		return fmt.Errorf("apply config file %q: %w", path, err)
+	}
+
+	return nil
+}
+
+// ApplyConfig is like ApplyConfigFile, but for the config file contents.
+func ApplyConfig(cmd *cobra.Command, data []byte, opts ConfigFileOptions) error {
+	var config map[string]interface{}
+	if err := yaml_v3.Unmarshal(data, &config); err != nil {
+		return fmt.Errorf("unmarshal config: %w", err)
+	}
+
+	if !opts.AllowUnknownKeys {
+		if err := validateConfigSection(cmd.Root(), config, ""); err != nil {
+			return fmt.Errorf("validate config: %w", err)
+		}
+	}
+
+	values, err := collectConfigValues(cmd, config)
+	if err != nil {
+		return fmt.Errorf("collect config values: %w", err)
+	}
+
+	flagNames := make([]string, 0, len(values))
+	for name := range values {
+		flagNames = append(flagNames, name)
+	}
+	sort.Strings(flagNames)
+
+	for _, name := range flagNames {
+		flag := cmd.Flag(name)
+		if flag == nil {
+			continue
+		}
+
+		// Deprecated aliases set the aliased flag, unless the config has the aliased flag itself.
+		aliasValue, isAlias := flag.Value.(*flagAliasValue)
+		if isAlias {
+			if _, ok := values[aliasValue.flag.Name]; ok {
+				continue
+			}
+
+			flag = aliasValue.flag
+		}
+
+		if flag.Changed {
+			continue
+		}
+
+		if err := setFlagFromConfig(flag, values[name].value); err != nil {
+			return fmt.Errorf("config key %q is not valid: %w", values[name].keyPath, err)
+		}
+
+		flag.Changed = true
+		saveFlagValueSource(flag, FlagValueSourceConfig, nil, values[name].keyPath)
+
+		if isAlias {
+			addFlagDeprecationWarning(flag, fmt.Sprintf("config key %q is deprecated: %s", values[name].keyPath, aliasValue.message))
+		}
+	}
+
+	return nil
+}
+
+type configValue struct {
+	keyPath string
+	value   interface{}
+}
+
+// Collect flag values from the root section and from the sections along the command path, the
+// deeper sections override the values from the enclosing ones.
+func collectConfigValues(cmd *cobra.Command, config map[string]interface{}) (map[string]configValue, error) {
+	var sectionNames []string
+	for c := cmd; c.HasParent(); c = c.Parent() {
+		sectionNames = append([]string{c.Name()}, sectionNames...)
+	}
+
+	values := map[string]configValue{}
+
+	section := config
+	sectionCmd := cmd.Root()
+	keyPath := ""
+	for i := 0; ; i++ {
+		for key, value := range section {
+			if findSubCommand(sectionCmd, key) != nil {
+				continue
+			}
+
+			values[key] = configValue{keyPath: joinConfigKeyPath(keyPath, key), value: value}
+		}
+
+		if i == len(sectionNames) {
+			break
+		}
+
+		sectionCmd = findSubCommand(sectionCmd, sectionNames[i])
+		keyPath = joinConfigKeyPath(keyPath, sectionNames[i])
+
+		nextSection, ok := section[sectionNames[i]]
+		if !ok || nextSection == nil {
+			break
+		}
+
+		section, ok = nextSection.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("config key %q must be a map of flags and subcommand sections", keyPath)
+		}
+	}
+
+	return values, nil
+}
+
+func validateConfigSection(sectionCmd *cobra.Command, section map[string]interface{}, keyPath string) error {
+	keys := make([]string, 0, len(section))
+	for key := range section {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		subKeyPath := joinConfigKeyPath(keyPath, key)
+
+		if subCmd := findSubCommand(sectionCmd, key); subCmd != nil {
+			if section[key] == nil {
+				continue
+			}
+
+			subSection, ok := section[key].(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("config key %q must be a map of flags and subcommand sections", subKeyPath)
+			}
+
+			if err := validateConfigSection(subCmd, subSection, subKeyPath); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if !commandTreeHasFlag(sectionCmd, key) {
+			return fmt.Errorf("unknown config key %q: no such flag or subcommand for command %q", subKeyPath, sectionCmd.CommandPath())
+		}
+	}
+
+	return nil
+}
+
+func findSubCommand(cmd *cobra.Command, name string) *cobra.Command {
+	for _, subCmd := range cmd.Commands() {
+		if subCmd.Name() == name {
+			return subCmd
+		}
+	}
+
+	return nil
+}
+
+// Check whether the command, any of its subcommands or parents (persistent flags only) has the flag.
+func commandTreeHasFlag(cmd *cobra.Command, name string) bool {
+	if cmd.Flag(name) != nil {
+		return true
+	}
+
+	for _, subCmd := range cmd.Commands() {
+		if commandTreeHasFlag(subCmd, name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func setFlagFromConfig(flag *pflag.Flag, value interface{}) error {
+	switch val := value.(type) {
+	case nil:
+		return nil
+	case []interface{}:
+		sliceValue, ok := flag.Value.(pflag.SliceValue)
+		if !ok {
+			return fmt.Errorf("list is not allowed for flag %q of type %s", flag.Name, flag.Value.Type())
+		}
+
+		var items []string
+		for _, item := range val {
+			itemStr, err := configScalarToString(item)
+			if err != nil {
+				return err
+			}
+
+			items = append(items, itemStr)
+		}
+
+		if err := sliceValue.Replace(items); err != nil {
+			return fmt.Errorf("set flag %q: %w", flag.Name, err)
+		}
+	case map[string]interface{}:
+		if flag.Value.Type() != "stringToString" {
+			return fmt.Errorf("map is not allowed for flag %q of type %s", flag.Name, flag.Value.Type())
+		}
+
+		keys := make([]string, 0, len(val))
+		for key := range val {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			itemStr, err := configScalarToString(val[key])
+			if err != nil {
+				return err
+			}
+
+			if err := flag.Value.Set(fmt.Sprintf("%s=%s", key, itemStr)); err != nil {
+				return fmt.Errorf("set flag %q: %w", flag.Name, err)
+			}
+		}
+	default:
+		valStr, err := configScalarToString(val)
+		if err != nil {
+			return err
+		}
+
+		if err := flag.Value.Set(valStr); err != nil {
+			return fmt.Errorf("set flag %q: %w", flag.Name, err)
+		}
+	}
+
+	return nil
+}
+
+func configScalarToString(value interface{}) (string, error) {
+	switch val := value.(type) {
+	case string:
+		return val, nil
+	case bool, int, int64, uint64, float64:
+		return fmt.Sprint(val), nil
+	default:
+		return "", fmt.Errorf("unexpected value of type %T", value)
+	}
+}
+
+func joinConfigKeyPath(keyPath, key string) string {
+	if keyPath == "" {
+		return key
+	}
+
+	return strings.Join([]string{keyPath, key}, ".")
+}
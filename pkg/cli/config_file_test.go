@@ -0,0 +1,148 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/cobra"
+
+	"github.com/werf/common-go/pkg/util"
+)
+
+var _ = Describe("ApplyConfig", func() {
+	It("should prefer cli args over env vars over config over defaults", func() {
+		registry := newTestRegistry(util.MapEnvProvider{
+			"TEST_RUN_ENV_OVER_CONFIG": "env",
+		})
+
+		cmd := newTestCommand()
+
+		var defaultOnly, configOnly, envOverConfig, cliOverEnv string
+		Expect(AddFlag(cmd, &defaultOnly, "default-only", "default", "", AddFlagOptions{Registry: registry})).To(Succeed())
+		Expect(AddFlag(cmd, &configOnly, "config-only", "default", "", AddFlagOptions{Registry: registry})).To(Succeed())
+		Expect(AddFlag(cmd, &envOverConfig, "env-over-config", "default", "", AddFlagOptions{Registry: registry})).To(Succeed())
+		Expect(AddFlag(cmd, &cliOverEnv, "cli-over-config", "default", "", AddFlagOptions{Registry: registry})).To(Succeed())
+
+		Expect(cmd.ParseFlags([]string{"--cli-over-config=cli"})).To(Succeed())
+		Expect(ApplyConfig(cmd, []byte(`
+config-only: config
+env-over-config: config
+cli-over-config: config
+`), ConfigFileOptions{})).To(Succeed())
+
+		Expect(defaultOnly).To(Equal("default"))
+		Expect(configOnly).To(Equal("config"))
+		Expect(envOverConfig).To(Equal("env"))
+		Expect(cliOverEnv).To(Equal("cli"))
+		Expect(cmd.Flag("config-only").Changed).To(BeTrue())
+		Expect(cmd.Flag("default-only").Changed).To(BeFalse())
+	})
+
+	It("should override top-level values with the command section values", func() {
+		cmd := newTestCommand()
+		registry := newTestRegistry(nil)
+
+		var (
+			timeout time.Duration
+			workers int
+			values  []string
+			labels  map[string]string
+		)
+		Expect(AddFlag(cmd, &timeout, "timeout", time.Minute, "", AddFlagOptions{Registry: registry})).To(Succeed())
+		Expect(AddFlag(cmd, &workers, "workers", 1, "", AddFlagOptions{Registry: registry})).To(Succeed())
+		Expect(AddFlag(cmd, &values, "values", []string{"default.yaml"}, "", AddFlagOptions{Registry: registry})).To(Succeed())
+		Expect(AddFlag(cmd, &labels, "labels", nil, "", AddFlagOptions{Registry: registry})).To(Succeed())
+
+		Expect(ApplyConfig(cmd, []byte(`{
+  "timeout": "1m30s",
+  "workers": 2,
+  "run": {
+    "workers": 8,
+    "values": ["a.yaml", "b.yaml"],
+    "labels": {"team": "core", "env": "dev"}
+  }
+}`), ConfigFileOptions{})).To(Succeed())
+
+		Expect(timeout).To(Equal(90 * time.Second))
+		Expect(workers).To(Equal(8))
+		Expect(values).To(Equal([]string{"a.yaml", "b.yaml"}))
+		Expect(labels).To(Equal(map[string]string{"team": "core", "env": "dev"}))
+	})
+
+	It("should apply persistent flags of the root command", func() {
+		cmd := newTestCommand()
+		cmd.Root().PersistentFlags().String("kube-context", "", "")
+
+		Expect(ApplyConfig(cmd, []byte("kube-context: production"), ConfigFileOptions{})).To(Succeed())
+		Expect(cmd.Flag("kube-context").Value.String()).To(Equal("production"))
+	})
+
+	It("should fail on unknown keys unless allowed", func() {
+		cmd := newTestCommand()
+		registry := newTestRegistry(nil)
+
+		var workers int
+		Expect(AddFlag(cmd, &workers, "workers", 1, "", AddFlagOptions{Registry: registry})).To(Succeed())
+
+		Expect(ApplyConfig(cmd, []byte("run:\n  wokers: 2\n"), ConfigFileOptions{})).To(MatchError(ContainSubstring(`unknown config key "run.wokers"`)))
+		Expect(ApplyConfig(cmd, []byte("run: 2\n"), ConfigFileOptions{})).To(MatchError(ContainSubstring(`config key "run" must be a map`)))
+		Expect(ApplyConfig(cmd, []byte("run:\n  wokers: 2\n"), ConfigFileOptions{AllowUnknownKeys: true})).To(Succeed())
+		Expect(workers).To(Equal(1))
+	})
+
+	It("should validate config values like other values", func() {
+		cmd := newTestCommand()
+		registry := newTestRegistry(nil)
+
+		var (
+			strategy string
+			workers  int
+		)
+		Expect(AddFlag(cmd, &strategy, "strategy", "rolling", "", AddFlagOptions{Registry: registry, AllowedValues: []string{"rolling", "recreate"}})).To(Succeed())
+		Expect(AddFlag(cmd, &workers, "workers", 1, "", AddFlagOptions{Registry: registry})).To(Succeed())
+
+		Expect(ApplyConfig(cmd, []byte("strategy: canary"), ConfigFileOptions{})).To(MatchError(ContainSubstring(`config key "strategy" is not valid`)))
+		Expect(ApplyConfig(cmd, []byte("workers: [1, 2]"), ConfigFileOptions{})).To(MatchError(ContainSubstring("list is not allowed")))
+	})
+
+	It("should satisfy required flags set only in the config file", func() {
+		cmd := newTestCommand()
+		registry := newTestRegistry(nil)
+
+		var release string
+		Expect(AddFlag(cmd, &release, "release", "", "", AddFlagOptions{Registry: registry, Required: true})).To(Succeed())
+
+		configPath := filepath.Join(GinkgoT().TempDir(), "config.yaml")
+		Expect(os.WriteFile(configPath, []byte("release: app\n"), 0o644)).To(Succeed())
+
+		cmd.Root().PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+			return ApplyConfigFile(cmd, configPath, ConfigFileOptions{})
+		}
+		cmd.Root().SetArgs([]string{"run"})
+
+		Expect(cmd.Root().Execute()).To(Succeed())
+		Expect(release).To(Equal("app"))
+	})
+
+	It("should read the config file", func() {
+		cmd := newTestCommand()
+		registry := newTestRegistry(nil)
+
+		var workers int
+		Expect(AddFlag(cmd, &workers, "workers", 1, "", AddFlagOptions{Registry: registry})).To(Succeed())
+
+		configPath := filepath.Join(GinkgoT().TempDir(), "config.yaml")
+		Expect(os.WriteFile(configPath, []byte("workers: 4\n"), 0o644)).To(Succeed())
+
+		Expect(ApplyConfigFile(cmd, "", ConfigFileOptions{})).To(Succeed())
+		Expect(workers).To(Equal(1))
+
+		Expect(ApplyConfigFile(cmd, configPath, ConfigFileOptions{})).To(Succeed())
+		Expect(workers).To(Equal(4))
+
+		Expect(ApplyConfigFile(cmd, configPath+".missing", ConfigFileOptions{})).To(MatchError(ContainSubstring("read config file")))
+	})
+})
@@ -3,9 +3,11 @@ package cli
 import (
 	"encoding/csv"
 	"fmt"
-	"os"
+	"net"
+	"net/url"
 	"regexp"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -14,6 +16,15 @@ import (
 	"github.com/spf13/pflag"
 )
 
+const (
+	// Env vars in human-readable form, e.g. "$NELM_RELEASE_NAME".
+	FlagEnvVarsAnnotationName = "env-vars"
+	// Help without env vars, allowed values and examples appended.
+	FlagDescriptionAnnotationName = "description"
+	// Example values of the flag, e.g. "app=web".
+	FlagExamplesAnnotationName = "examples"
+)
+
 type FlagType string
 
 const (
@@ -25,14 +36,47 @@ type AddFlagOptions struct {
 	// This function must return a slice of regexps to be matched agains all environment variables.
 	// Values of matched environment variables will become the flag value. Values priority (from
 	// lowest to highest): flag default value -> environment variable value (from first to last
-	// regexp; if regexp matches multiple env vars then the last has higher priority) -> cli flag
-	// value. For slice and map-type flags: all env vars values and all cli flags values are joined.
+	// regexp; if regexp matches multiple env vars then the lexically last has higher priority) ->
+	// cli flag value. For slice and map-type flags: all env vars values are joined in the priority
+	// order. If multiple env vars match a scalar flag, the conflict is recorded in the registry,
+	// see Registry.FlagEnvVarConflicts.
 	GetEnvVarRegexesFunc GetFlagEnvVarRegexesInterface
 
+	// Registry for the env var regexes of the flag. Defaults to DefaultRegistry. If
+	// GetEnvVarRegexesFunc is not set, the registry methods are used, with the registry env vars
+	// prefix.
+	Registry *Registry
+
 	// Group info is saved in Flag annotations, which can be used later, e.g. for grouping flags in
 	// the --help output.
 	Group *FlagGroup
 
+	// If not empty, only these values are allowed, both in cli args and env vars. Allowed values
+	// are shown in the help and used for the shell completion. For slice flags each element is
+	// checked.
+	AllowedValues []string
+	// Example values, e.g. "app=web" for --labels. Shown in the help as "--labels=app=web" and
+	// in the reference docs.
+	Examples []string
+
+	// If set, called for each value (each element for slice flags) from cli args and env vars.
+	ValidateValue func(value string) error
+
+	// Names of groups of flags of the command, of which at most one might be set, all or none must
+	// be set, or at least one must be set, respectively. A flag set from env vars or a config file
+	// counts as set. Checked by ValidateFlagConstraints.
+	MutuallyExclusiveGroups []string
+	RequiredTogetherGroups  []string
+	OneRequiredGroups       []string
+
+	// Completes the flag value in the shell, e.g. CompleteFilesWithExtensions. Defaults to
+	// completing AllowedValues, if any.
+	CompletionFunc CompletionFunc
+
+	// Old names of the renamed flag. Aliases are hidden, but set the flag value from cli args
+	// and env vars (with a lower priority than the flag env vars), see WarnDeprecatedFlagAliases.
+	DeprecatedAliases []FlagAlias
+
 	Type            FlagType
 	ShortName       string
 	Deprecated      bool
@@ -41,15 +85,15 @@ type AddFlagOptions struct {
 	NoSplitOnCommas bool
 }
 
-// TODO(ilya-lesikov): allow restricted values
-// TODO(ilya-lesikov): allow showing restricted values in usage
-// TODO(ilya-lesikov): pass examples separately from help
 // TODO(ilya-lesikov): allow for []string with no comma-separated values (pflag.StringArrayVar?)
 // TODO(ilya-lesikov): allow for map[string]string with no comma-separated values
 
 // Create and bind a flag to the Cobra command. Corresponding environment variables (if enabled)
 // parsed and the value is assigned to the flag immediately. Flag value type inferred from
-// destination arg.
+// destination arg. Supported types: bool, int, int64, uint, float64, string, time.Duration,
+// net.IP, *url.URL, ByteSize, Quantity, Percentage, []string, []int, []bool, map[string]string and any type
+// implementing pflag.Value (or pflag.SliceValue for multi-value flags, which get values from
+// multiple env vars like []string).
 func AddFlag[T any](cmd *cobra.Command, dest *T, name string, defaultValue T, help string, opts AddFlagOptions) error {
 	opts, err := applyAddOptionsDefaults(opts, dest)
 	if err != nil {
@@ -61,7 +105,12 @@ func AddFlag[T any](cmd *cobra.Command, dest *T, name string, defaultValue T, he
 		return fmt.Errorf("get env var names: %w", err)
 	}
 
-	help, err = buildHelp(help, dest, envVarRegexExprs)
+	description := help
+	if !strings.HasSuffix(description, ".") {
+		description += "."
+	}
+
+	help, err = buildHelp(help, name, dest, opts.AllowedValues, opts.Examples, envVarRegexExprs)
 	if err != nil {
 		return fmt.Errorf("build help: %w", err)
 	}
@@ -70,6 +119,30 @@ func AddFlag[T any](cmd *cobra.Command, dest *T, name string, defaultValue T, he
 		return fmt.Errorf("add flags: %w", err)
 	}
 
+	if err := saveFlagDocMetadata(cmd, name, description, opts.Examples, envVarRegexExprs); err != nil {
+		return fmt.Errorf("save flag doc metadata: %w", err)
+	}
+
+	if len(opts.AllowedValues) > 0 || opts.ValidateValue != nil {
+		if err := restrictFlagValues(cmd, name, opts.AllowedValues, opts.ValidateValue, !opts.NoSplitOnCommas); err != nil {
+			return fmt.Errorf("restrict flag values: %w", err)
+		}
+	}
+
+	trackFlagValueSource(cmd.Flags().Lookup(name))
+
+	if opts.CompletionFunc == nil && len(opts.AllowedValues) > 0 {
+		opts.CompletionFunc = CompleteStaticValues(lo.Map(opts.AllowedValues, func(value string, _ int) CompletionValue {
+			return CompletionValue{Value: value}
+		})...)
+	}
+
+	if opts.CompletionFunc != nil {
+		if err := cmd.RegisterFlagCompletionFunc(name, opts.CompletionFunc); err != nil {
+			return fmt.Errorf("register completion func: %w", err)
+		}
+	}
+
 	if opts.Hidden {
 		if err := cmd.Flags().MarkHidden(name); err != nil {
 			return fmt.Errorf("mark flag as hidden: %w", err)
@@ -88,10 +161,19 @@ func AddFlag[T any](cmd *cobra.Command, dest *T, name string, defaultValue T, he
 		}
 	}
 
-	if err := processEnvVars(cmd, envVarRegexExprs, name, dest); err != nil {
+	aliasesEnvVarRegexExprs, err := buildFlagAliasesEnvVarRegexes(cmd, opts.DeprecatedAliases, opts.GetEnvVarRegexesFunc)
+	if err != nil {
+		return fmt.Errorf("build deprecated aliases env var regexes: %w", err)
+	}
+
+	if err := processEnvVars(cmd, opts.Registry, append(aliasesEnvVarRegexExprs, envVarRegexExprs...), name, dest); err != nil {
 		return fmt.Errorf("process env vars: %w", err)
 	}
 
+	if err := addFlagAliases(cmd, name, opts.DeprecatedAliases, aliasesEnvVarRegexExprs, envVarRegexExprs); err != nil {
+		return fmt.Errorf("add deprecated aliases: %w", err)
+	}
+
 	switch opts.Type {
 	case FlagTypeDir:
 		if err := cmd.MarkFlagDirname(name); err != nil {
@@ -103,6 +185,10 @@ func AddFlag[T any](cmd *cobra.Command, dest *T, name string, defaultValue T, he
 		}
 	}
 
+	if err := saveFlagConstraintsMetadata(cmd, name, opts); err != nil {
+		return fmt.Errorf("save flag constraints metadata: %w", err)
+	}
+
 	if opts.Group != nil {
 		if err := saveFlagGroupMetadata(cmd, name, opts.Group); err != nil {
 			return fmt.Errorf("save flag group metadata: %w", err)
@@ -113,12 +199,15 @@ func AddFlag[T any](cmd *cobra.Command, dest *T, name string, defaultValue T, he
 }
 
 func applyAddOptionsDefaults[T any](opts AddFlagOptions, dest *T) (AddFlagOptions, error) {
+	opts.Registry = registryOrDefault(opts.Registry)
+
 	if opts.GetEnvVarRegexesFunc == nil {
+		// Slice values are checked first, because pflag.SliceValue is also a pflag.Value.
 		switch dst := any(dest).(type) {
-		case *bool, *int, *string, *time.Duration:
-			opts.GetEnvVarRegexesFunc = GetFlagLocalEnvVarRegexes
-		case *[]string, *map[string]string:
-			opts.GetEnvVarRegexesFunc = GetFlagLocalMultiEnvVarRegexes
+		case *[]string, *[]int, *[]bool, *map[string]string, pflag.SliceValue:
+			opts.GetEnvVarRegexesFunc = opts.Registry.GetFlagLocalMultiEnvVarRegexes
+		case *bool, *int, *int64, *uint, *float64, *string, *time.Duration, *net.IP, **url.URL, *ByteSize, *Quantity, *Percentage, pflag.Value:
+			opts.GetEnvVarRegexesFunc = opts.Registry.GetFlagLocalEnvVarRegexes
 		default:
 			return AddFlagOptions{}, fmt.Errorf("unsupported type %T", dst)
 		}
@@ -127,11 +216,19 @@ func applyAddOptionsDefaults[T any](opts AddFlagOptions, dest *T) (AddFlagOption
 	return opts, nil
 }
 
-func buildHelp[T any](help string, dest *T, envVarRegexes []*FlagRegexExpr) (string, error) {
+func buildHelp[T any](help, name string, dest *T, allowedValues, examples []string, envVarRegexes []*FlagRegexExpr) (string, error) {
 	if !strings.HasSuffix(help, ".") {
 		help += "."
 	}
 
+	if len(allowedValues) > 0 {
+		help = fmt.Sprintf("%s Allowed values: %s.", help, strings.Join(allowedValues, ", "))
+	}
+
+	if len(examples) > 0 {
+		help = fmt.Sprintf("%s %s: %s.", help, lo.Ternary(len(examples) == 1, "Example", "Examples"), strings.Join(formatFlagExamples(name, examples), ", "))
+	}
+
 	if len(envVarRegexes) == 0 {
 		return help, nil
 	} else if len(envVarRegexes) == 1 {
@@ -154,6 +251,12 @@ func addFlags[T any](cmd *cobra.Command, dest *T, name string, shortName string,
 		cmd.Flags().BoolVarP(dst, name, shortName, any(defaultValue).(bool), help)
 	case *int:
 		cmd.Flags().IntVarP(dst, name, shortName, any(defaultValue).(int), help)
+	case *int64:
+		cmd.Flags().Int64VarP(dst, name, shortName, any(defaultValue).(int64), help)
+	case *uint:
+		cmd.Flags().UintVarP(dst, name, shortName, any(defaultValue).(uint), help)
+	case *float64:
+		cmd.Flags().Float64VarP(dst, name, shortName, any(defaultValue).(float64), help)
 	case *string:
 		cmd.Flags().StringVarP(dst, name, shortName, any(defaultValue).(string), help)
 	case *[]string:
@@ -162,10 +265,32 @@ func addFlags[T any](cmd *cobra.Command, dest *T, name string, shortName string,
 		} else {
 			cmd.Flags().StringSliceVarP(dst, name, shortName, any(defaultValue).([]string), help)
 		}
+	case *[]int:
+		*dst = any(defaultValue).([]int)
+		cmd.Flags().VarP(&intListValue{dest: dst}, name, shortName, help)
+	case *[]bool:
+		cmd.Flags().BoolSliceVarP(dst, name, shortName, any(defaultValue).([]bool), help)
 	case *map[string]string:
 		cmd.Flags().StringToStringVarP(dst, name, shortName, any(defaultValue).(map[string]string), help)
 	case *time.Duration:
 		cmd.Flags().DurationVarP(dst, name, shortName, any(defaultValue).(time.Duration), help)
+	case *net.IP:
+		cmd.Flags().IPVarP(dst, name, shortName, any(defaultValue).(net.IP), help)
+	case **url.URL:
+		*dst = any(defaultValue).(*url.URL)
+		cmd.Flags().VarP(&urlValue{dest: dst}, name, shortName, help)
+	case *ByteSize:
+		*dst = any(defaultValue).(ByteSize)
+		cmd.Flags().VarP(&byteSizeValue{dest: dst}, name, shortName, help)
+	case *Quantity:
+		*dst = any(defaultValue).(Quantity)
+		cmd.Flags().VarP(&quantityValue{dest: dst}, name, shortName, help)
+	case *Percentage:
+		*dst = any(defaultValue).(Percentage)
+		cmd.Flags().VarP(&percentageValue{dest: dst}, name, shortName, help)
+	case pflag.Value:
+		*dest = defaultValue
+		cmd.Flags().VarP(dst, name, shortName, help)
 	default:
 		return fmt.Errorf("unsupported type %T", dst)
 	}
@@ -173,82 +298,70 @@ func addFlags[T any](cmd *cobra.Command, dest *T, name string, shortName string,
 	return nil
 }
 
-func processEnvVars[T any](cmd *cobra.Command, envVarRegexExprs []*FlagRegexExpr, flagName string, dest T) error {
+func processEnvVars[T any](cmd *cobra.Command, registry *Registry, envVarRegexExprs []*FlagRegexExpr, flagName string, dest T) error {
+	var regexes []*regexp.Regexp
 	for _, regExpr := range envVarRegexExprs {
 		regex, err := regexp.Compile(fmt.Sprintf(`%s`, regExpr.Expr))
 		if err != nil {
 			return fmt.Errorf("compile regex %q: %w", regExpr.Expr, err)
 		}
 
-		definedFlagEnvVarRegexes[*regExpr] = regex
+		regexes = append(regexes, regex)
+		registry.defineFlagEnvVarRegex(*regExpr, regex)
 	}
 
-	lo.Reverse(envVarRegexExprs)
-
-	environ := os.Environ()
-	sort.Strings(environ)
-
-	envir := map[string]string{}
-	for _, keyValue := range environ {
-		parts := strings.SplitN(keyValue, "=", 2)
-		envir[parts[0]] = parts[1]
+	envVars := matchFlagEnvVars(registry.EnvProvider().Environ(), regexes)
+	if len(envVars) == 0 {
+		return nil
 	}
 
-	envs := map[string]string{}
-	for key, val := range envir {
-		for _, regexExpr := range envVarRegexExprs {
-			if !definedFlagEnvVarRegexes[*regexExpr].MatchString(key) || val == "" {
-				continue
-			}
+	flag := cmd.Flag(flagName)
+	flag.Changed = true
 
-			envs[key] = val
-		}
-	}
+	envVarNames := lo.Map(envVars, func(envVar matchedEnvVar, _ int) string { return envVar.name })
 
+	// Slice values are checked first, because pflag.SliceValue is also a pflag.Value.
 	switch dst := any(dest).(type) {
-	case *bool, *int, *string, *time.Duration:
-	envirLoop:
-		for key, val := range envir {
-			for _, regexExpr := range envVarRegexExprs {
-				if !definedFlagEnvVarRegexes[*regexExpr].MatchString(key) || val == "" {
-					continue
-				}
-
-				flag := cmd.Flag(flagName)
-				flag.Changed = true
-
-				if err := flag.Value.Set(val); err != nil {
-					return fmt.Errorf("environment variable %q value %q is not valid: %w", key, val, err)
-				}
-
-				break envirLoop
-			}
-		}
-	case *[]string:
-		for key, val := range envs {
-			parts, err := splitComma(val)
+	case *[]string, *[]int, *[]bool, pflag.SliceValue:
+		for _, envVar := range envVars {
+			parts, err := splitComma(envVar.value)
 			if err != nil {
-				return fmt.Errorf("split comma-separated environment variable %q with value %q: %w", key, val, err)
+				return fmt.Errorf("split comma-separated environment variable %q with value %q: %w", envVar.name, envVar.value, err)
 			}
 
 			for _, part := range parts {
-				flag := cmd.Flag(flagName)
-				flag.Changed = true
-
 				if err := flag.Value.(pflag.SliceValue).Append(part); err != nil {
-					return fmt.Errorf("environment variable %q value %q is not valid: %w", key, val, err)
+					return fmt.Errorf("environment variable %q value %q is not valid: %w", envVar.name, envVar.value, err)
 				}
 			}
 		}
-	case *map[string]string:
-		for key, val := range envs {
-			flag := cmd.Flag(flagName)
-			flag.Changed = true
 
-			if err := flag.Value.Set(val); err != nil {
-				return fmt.Errorf("environment variable %q value %q is not valid: %w", key, val, err)
+		saveFlagValueSource(flag, FlagValueSourceEnv, envVarNames, "")
+	case *bool, *int, *int64, *uint, *float64, *string, *time.Duration, *net.IP, **url.URL, *ByteSize, *Quantity, *Percentage, pflag.Value:
+		envVar := envVars[len(envVars)-1]
+
+		if err := flag.Value.Set(envVar.value); err != nil {
+			return fmt.Errorf("environment variable %q value %q is not valid: %w", envVar.name, envVar.value, err)
+		}
+
+		if len(envVars) > 1 {
+			registry.addFlagEnvVarConflict(FlagEnvVarConflict{
+				CommandPath:    cmd.CommandPath(),
+				FlagName:       flagName,
+				UsedEnvVar:     envVar.name,
+				IgnoredEnvVars: envVarNames[:len(envVarNames)-1],
+			})
+		}
+
+		saveFlagValueSource(flag, FlagValueSourceEnv, []string{envVar.name}, "")
+	case *map[string]string:
+		for _, envVar := range envVars {
+			if err := flag.Value.Set(envVar.value); err != nil {
+				return fmt.Errorf("environment variable %q value %q is not valid: %w", envVar.name, envVar.value, err)
 			}
 		}
+
+		saveFlagValueSource(flag, FlagValueSourceEnv, envVarNames, "")
 	default:
 		return fmt.Errorf("unsupported type %T", dst)
 	}
@@ -256,6 +369,39 @@ func processEnvVars[T any](cmd *cobra.Command, envVarRegexExprs []*FlagRegexExpr
 	return nil
 }
 
+type matchedEnvVar struct {
+	name  string
+	value string
+}
+
+// Return non-empty env vars matching the regexes, ordered from the lowest to the highest
+// priority: by the regex order, then lexically by name. An env var matching multiple regexes
+// gets the priority of the last one.
+func matchFlagEnvVars(environ []string, regexes []*regexp.Regexp) []matchedEnvVar {
+	byRegex := make([][]matchedEnvVar, len(regexes))
+	for _, keyValue := range environ {
+		name, value, _ := strings.Cut(keyValue, "=")
+		if value == "" {
+			continue
+		}
+
+		for i := len(regexes) - 1; i >= 0; i-- {
+			if regexes[i].MatchString(name) {
+				byRegex[i] = append(byRegex[i], matchedEnvVar{name: name, value: value})
+				break
+			}
+		}
+	}
+
+	var result []matchedEnvVar
+	for _, envVars := range byRegex {
+		sort.Slice(envVars, func(i, j int) bool { return envVars[i].name < envVars[j].name })
+		result = append(result, envVars...)
+	}
+
+	return result
+}
+
 func saveFlagGroupMetadata(cmd *cobra.Command, flagName string, group *FlagGroup) error {
 	if err := cmd.Flags().SetAnnotation(flagName, FlagGroupIDAnnotationName, []string{group.ID}); err != nil {
 		return fmt.Errorf("set group id annotation: %w", err)
@@ -272,6 +418,40 @@ func saveFlagGroupMetadata(cmd *cobra.Command, flagName string, group *FlagGroup
 	return nil
 }
 
+func saveFlagDocMetadata(cmd *cobra.Command, flagName, description string, examples []string, envVarRegexes []*FlagRegexExpr) error {
+	if err := cmd.Flags().SetAnnotation(flagName, FlagDescriptionAnnotationName, []string{description}); err != nil {
+		return fmt.Errorf("set description annotation: %w", err)
+	}
+
+	if len(examples) > 0 {
+		if err := cmd.Flags().SetAnnotation(flagName, FlagExamplesAnnotationName, examples); err != nil {
+			return fmt.Errorf("set examples annotation: %w", err)
+		}
+	}
+
+	if len(envVarRegexes) == 0 {
+		return nil
+	}
+
+	envVars := lo.Map(envVarRegexes, func(regex *FlagRegexExpr, _ int) string { return regex.Human })
+	if err := cmd.Flags().SetAnnotation(flagName, FlagEnvVarsAnnotationName, envVars); err != nil {
+		return fmt.Errorf("set env vars annotation: %w", err)
+	}
+
+	return nil
+}
+
+// Format example values as cli args, e.g. "--labels=app=web". Values with spaces are quoted.
+func formatFlagExamples(flagName string, examples []string) []string {
+	return lo.Map(examples, func(example string, _ int) string {
+		if example == "" || strings.ContainsAny(example, " \t") {
+			example = strconv.Quote(example)
+		}
+
+		return "--" + flagName + "=" + example
+	})
+}
+
 func splitComma(s string) ([]string, error) {
 	stringReader := strings.NewReader(s)
 	csvReader := csv.NewReader(stringReader)
@@ -0,0 +1,166 @@
+package cli
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+const (
+	// Old names of the flag and its env vars, e.g. "--old-name" or "$NELM_OLD_NAME".
+	FlagDeprecatedAliasesAnnotationName = "deprecated-aliases"
+	// Warnings about the used deprecated aliases, which are not printed yet.
+	FlagDeprecationWarningsAnnotationName = "deprecation-warnings"
+)
+
+// FlagAlias is an old name of a renamed flag. The alias is hidden from help, but still sets the
+// flag value, both from cli args and env vars, with a migration message printed by
+// WarnDeprecatedFlagAliases.
+type FlagAlias struct {
+	// Old flag name.
+	Name string
+	// Env vars of the old flag name, derived the same way as for the flag, are always used.
+	// These are additional old env var names, e.g. "NELM_OLD_NAME".
+	EnvVars []string
+	// Migration message, e.g. "use --new-name instead, the value format is the same". Defaults
+	// to the suggestion to use the new flag and its env vars.
+	Message string
+}
+
+// NewWarnDeprecatedFlagAliasesPreRunE returns a PersistentPreRunE function, which prints
+// warnings about used deprecated flag aliases, see WarnDeprecatedFlagAliases.
+func NewWarnDeprecatedFlagAliasesPreRunE() func(cmd *cobra.Command, args []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		return WarnDeprecatedFlagAliases(cmd)
+	}
+}
+
+// WarnDeprecatedFlagAliases prints a warning to the command stderr for each deprecated flag alias
+// (see AddFlagOptions.DeprecatedAliases) used in cli args or env vars. Each warning is printed
+// once. Should be called after the cli args are parsed, e.g. in PersistentPreRunE.
+func WarnDeprecatedFlagAliases(cmd *cobra.Command) error {
+	var warnings []string
+	visit := func(flag *pflag.Flag) {
+		warnings = append(warnings, flag.Annotations[FlagDeprecationWarningsAnnotationName]...)
+		delete(flag.Annotations, FlagDeprecationWarningsAnnotationName)
+	}
+	cmd.LocalFlags().VisitAll(visit)
+	cmd.InheritedFlags().VisitAll(visit)
+
+	for _, warning := range lo.Uniq(warnings) {
+		if _, err := fmt.Fprintf(cmd.ErrOrStderr(), "Warning: %s\n", warning); err != nil {
+			return fmt.Errorf("write warning: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Return env var regexes of the aliases, which have lower priority than the flag env var regexes.
+func buildFlagAliasesEnvVarRegexes(cmd *cobra.Command, aliases []FlagAlias, getEnvVarRegexesFunc GetFlagEnvVarRegexesInterface) ([]*FlagRegexExpr, error) {
+	var result []*FlagRegexExpr
+	for _, alias := range aliases {
+		regexes, err := getEnvVarRegexesFunc(cmd, alias.Name)
+		if err != nil {
+			return nil, fmt.Errorf("get env var regexes of alias %q: %w", alias.Name, err)
+		}
+		result = append(result, regexes...)
+
+		for _, envVar := range alias.EnvVars {
+			result = append(result, NewFlagRegexExpr("^"+regexp.QuoteMeta(envVar)+"$", "$"+envVar))
+		}
+	}
+
+	return result, nil
+}
+
+func addFlagAliases(cmd *cobra.Command, flagName string, aliases []FlagAlias, aliasesEnvVarRegexes, envVarRegexes []*FlagRegexExpr) error {
+	if len(aliases) == 0 {
+		return nil
+	}
+
+	flag := cmd.Flags().Lookup(flagName)
+
+	var newEnvVars []string
+	for _, regex := range envVarRegexes {
+		newEnvVars = append(newEnvVars, regex.Human)
+	}
+
+	suggestion := "use --" + flagName + " instead"
+	if len(newEnvVars) > 0 {
+		suggestion = fmt.Sprintf("use --%s (or %s) instead", flagName, strings.Join(newEnvVars, ", "))
+	}
+
+	var aliasNames []string
+	for _, alias := range aliases {
+		message := lo.Ternary(alias.Message != "", alias.Message, suggestion)
+
+		cmd.Flags().AddFlag(&pflag.Flag{
+			Name:        alias.Name,
+			Usage:       fmt.Sprintf("Deprecated, %s.", message),
+			Value:       &flagAliasValue{Value: flag.Value, flag: flag, name: alias.Name, message: message},
+			DefValue:    flag.DefValue,
+			NoOptDefVal: flag.NoOptDefVal,
+			Hidden:      true,
+		})
+
+		aliasNames = append(aliasNames, "--"+alias.Name)
+	}
+
+	// The env var used for the flag value, if it belongs to an alias.
+	if envVars := flag.Annotations[FlagValueEnvVarsAnnotationName]; firstAnnotation(flag, FlagValueSourceAnnotationName) == string(FlagValueSourceEnv) {
+		for _, envVar := range envVars {
+			for _, regex := range aliasesEnvVarRegexes {
+				if regexp.MustCompile(regex.Expr).MatchString(envVar) {
+					addFlagDeprecationWarning(flag, fmt.Sprintf("environment variable $%s is deprecated: %s", envVar, suggestion))
+					break
+				}
+			}
+		}
+	}
+
+	for _, regex := range aliasesEnvVarRegexes {
+		aliasNames = append(aliasNames, regex.Human)
+	}
+
+	if err := cmd.Flags().SetAnnotation(flagName, FlagDeprecatedAliasesAnnotationName, aliasNames); err != nil {
+		return fmt.Errorf("set deprecated aliases annotation: %w", err)
+	}
+
+	return nil
+}
+
+func addFlagDeprecationWarning(flag *pflag.Flag, warning string) {
+	if flag.Annotations == nil {
+		flag.Annotations = map[string][]string{}
+	}
+
+	flag.Annotations[FlagDeprecationWarningsAnnotationName] = append(flag.Annotations[FlagDeprecationWarningsAnnotationName], warning)
+}
+
+var _ pflag.Value = (*flagAliasValue)(nil)
+
+// Sets the value of the aliased flag and records the deprecation warning.
+type flagAliasValue struct {
+	pflag.Value
+
+	// The aliased flag.
+	flag    *pflag.Flag
+	name    string
+	message string
+}
+
+func (v *flagAliasValue) Set(s string) error {
+	if err := v.Value.Set(s); err != nil {
+		return err
+	}
+
+	v.flag.Changed = true
+	addFlagDeprecationWarning(v.flag, fmt.Sprintf("flag --%s is deprecated: %s", v.name, v.message))
+
+	return nil
+}
@@ -0,0 +1,119 @@
+package cli
+
+import (
+	"bytes"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/cobra"
+
+	"github.com/werf/common-go/pkg/util"
+)
+
+var _ = Describe("DeprecatedAliases", func() {
+	var (
+		cmd     *cobra.Command
+		stderr  *bytes.Buffer
+		release string
+		labels  []string
+	)
+
+	addFlags := func(env util.MapEnvProvider) {
+		registry := newTestRegistry(env)
+		cmd = newTestCommand()
+		stderr = &bytes.Buffer{}
+		cmd.SetErr(stderr)
+
+		Expect(AddFlag(cmd, &release, "release", "", "Release name", AddFlagOptions{
+			Registry:          registry,
+			DeprecatedAliases: []FlagAlias{{Name: "release-name", EnvVars: []string{"LEGACY_RELEASE"}}},
+		})).To(Succeed())
+		Expect(AddFlag(cmd, &labels, "labels", nil, "Labels", AddFlagOptions{
+			Registry:          registry,
+			DeprecatedAliases: []FlagAlias{{Name: "label", Message: "use --labels instead, it accepts comma-separated values"}},
+		})).To(Succeed())
+	}
+
+	It("should set the flag from the alias and warn once", func() {
+		addFlags(nil)
+
+		Expect(cmd.ParseFlags([]string{"--release-name=app", "--label=a", "--label=b"})).To(Succeed())
+		Expect(release).To(Equal("app"))
+		Expect(labels).To(Equal([]string{"a", "b"}))
+		Expect(cmd.Flags().Lookup("release").Changed).To(BeTrue())
+
+		origin, err := GetFlagValueOrigin(cmd, "release")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(origin.Source).To(Equal(FlagValueSourceCLI))
+
+		Expect(NewWarnDeprecatedFlagAliasesPreRunE()(cmd, nil)).To(Succeed())
+		Expect(WarnDeprecatedFlagAliases(cmd)).To(Succeed())
+		Expect(stderr.String()).To(Equal(
+			"Warning: flag --label is deprecated: use --labels instead, it accepts comma-separated values\n" +
+				"Warning: flag --release-name is deprecated: use --release (or $TEST_RUN_RELEASE) instead\n",
+		))
+	})
+
+	It("should set the flag from the old env vars", func() {
+		addFlags(util.MapEnvProvider{
+			"LEGACY_RELEASE": "app",
+		})
+
+		Expect(release).To(Equal("app"))
+		Expect(WarnDeprecatedFlagAliases(cmd)).To(Succeed())
+		Expect(stderr.String()).To(Equal("Warning: environment variable $LEGACY_RELEASE is deprecated: use --release (or $TEST_RUN_RELEASE) instead\n"))
+	})
+
+	It("should prefer the new env vars", func() {
+		addFlags(util.MapEnvProvider{
+			"TEST_RUN_RELEASE_NAME": "old",
+			"TEST_RUN_RELEASE":      "new",
+		})
+
+		Expect(release).To(Equal("new"))
+		Expect(WarnDeprecatedFlagAliases(cmd)).To(Succeed())
+		Expect(stderr.String()).To(BeEmpty())
+	})
+
+	It("should set the flag from the alias config key", func() {
+		addFlags(nil)
+
+		Expect(ApplyConfig(cmd, []byte("release-name: app\nlabel: [a, b]\n"), ConfigFileOptions{})).To(Succeed())
+		Expect(release).To(Equal("app"))
+		Expect(labels).To(Equal([]string{"a", "b"}))
+
+		origin, err := GetFlagValueOrigin(cmd, "release")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(origin.Source).To(Equal(FlagValueSourceConfig))
+		Expect(origin.ConfigKey).To(Equal("release-name"))
+
+		Expect(WarnDeprecatedFlagAliases(cmd)).To(Succeed())
+		Expect(stderr.String()).To(Equal(
+			"Warning: config key \"label\" is deprecated: use --labels instead, it accepts comma-separated values\n" +
+				"Warning: config key \"release-name\" is deprecated: use --release (or $TEST_RUN_RELEASE) instead\n",
+		))
+	})
+
+	It("should not override env vars with the alias config key", func() {
+		addFlags(util.MapEnvProvider{
+			"TEST_RUN_RELEASE": "env",
+		})
+
+		Expect(ApplyConfig(cmd, []byte("release-name: config\n"), ConfigFileOptions{})).To(Succeed())
+		Expect(release).To(Equal("env"))
+
+		origin, err := GetFlagValueOrigin(cmd, "release")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(origin.Source).To(Equal(FlagValueSourceEnv))
+		Expect(origin.EnvVars).To(Equal([]string{"TEST_RUN_RELEASE"}))
+	})
+
+	It("should hide aliases from help but list them in the reference", func() {
+		addFlags(nil)
+
+		Expect(cmd.Flags().Lookup("release-name").Hidden).To(BeTrue())
+		Expect(cmd.Flags().Lookup("release").Annotations[FlagDeprecatedAliasesAnnotationName]).To(Equal([]string{
+			"--release-name", "$TEST_RUN_RELEASE_NAME", "$LEGACY_RELEASE",
+		}))
+	})
+})
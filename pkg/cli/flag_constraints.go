@@ -0,0 +1,124 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+const (
+	FlagMutuallyExclusiveGroupsAnnotationName = "mutually-exclusive-groups"
+	FlagRequiredTogetherGroupsAnnotationName  = "required-together-groups"
+	FlagOneRequiredGroupsAnnotationName       = "one-required-groups"
+)
+
+// NewValidateFlagConstraintsPreRunE returns a PersistentPreRunE function, which validates the
+// flag constraints, see ValidateFlagConstraints.
+func NewValidateFlagConstraintsPreRunE() func(cmd *cobra.Command, args []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		return ValidateFlagConstraints(cmd)
+	}
+}
+
+// ValidateFlagConstraints checks the constraints declared with AddFlagOptions
+// MutuallyExclusiveGroups, RequiredTogetherGroups and OneRequiredGroups for the flags of the
+// command, including the inherited ones. A flag is considered set if its value came from cli
+// args, env vars or a config file. Should be called after the cli args are parsed, e.g. in
+// PersistentPreRunE. Errors name the flags along with their env vars.
+func ValidateFlagConstraints(cmd *cobra.Command) error {
+	var flags []*pflag.Flag
+	cmd.LocalFlags().VisitAll(func(flag *pflag.Flag) { flags = append(flags, flag) })
+	cmd.InheritedFlags().VisitAll(func(flag *pflag.Flag) { flags = append(flags, flag) })
+	flags = lo.UniqBy(flags, func(flag *pflag.Flag) string { return flag.Name })
+	sort.Slice(flags, func(i, j int) bool { return flags[i].Name < flags[j].Name })
+
+	var errs []error
+
+	for _, group := range buildFlagConstraintGroups(flags, FlagMutuallyExclusiveGroupsAnnotationName) {
+		setFlags := lo.Filter(group, func(flag *pflag.Flag, _ int) bool { return flag.Changed })
+		if len(setFlags) > 1 {
+			errs = append(errs, fmt.Errorf("flags %s are mutually exclusive, but %s are set", describeFlags(cmd, group, false), describeFlags(cmd, setFlags, true)))
+		}
+	}
+
+	for _, group := range buildFlagConstraintGroups(flags, FlagRequiredTogetherGroupsAnnotationName) {
+		setFlags, unsetFlags := lo.FilterReject(group, func(flag *pflag.Flag, _ int) bool { return flag.Changed })
+		if len(setFlags) > 0 && len(unsetFlags) > 0 {
+			errs = append(errs, fmt.Errorf("flags %s must be set together, but %s are set and %s are not", describeFlags(cmd, group, false), describeFlags(cmd, setFlags, true), describeFlags(cmd, unsetFlags, true)))
+		}
+	}
+
+	for _, group := range buildFlagConstraintGroups(flags, FlagOneRequiredGroupsAnnotationName) {
+		if !lo.SomeBy(group, func(flag *pflag.Flag) bool { return flag.Changed }) {
+			errs = append(errs, fmt.Errorf("at least one of flags %s must be set", describeFlags(cmd, group, true)))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func saveFlagConstraintsMetadata(cmd *cobra.Command, flagName string, opts AddFlagOptions) error {
+	for annotationName, groups := range map[string][]string{
+		FlagMutuallyExclusiveGroupsAnnotationName: opts.MutuallyExclusiveGroups,
+		FlagRequiredTogetherGroupsAnnotationName:  opts.RequiredTogetherGroups,
+		FlagOneRequiredGroupsAnnotationName:       opts.OneRequiredGroups,
+	} {
+		if len(groups) == 0 {
+			continue
+		}
+
+		if err := cmd.Flags().SetAnnotation(flagName, annotationName, groups); err != nil {
+			return fmt.Errorf("set %s annotation: %w", annotationName, err)
+		}
+	}
+
+	return nil
+}
+
+// Return flags of each group with the annotation, groups are sorted by name.
+func buildFlagConstraintGroups(flags []*pflag.Flag, annotationName string) [][]*pflag.Flag {
+	groups := map[string][]*pflag.Flag{}
+	for _, flag := range flags {
+		for _, groupName := range flag.Annotations[annotationName] {
+			groups[groupName] = append(groups[groupName], flag)
+		}
+	}
+
+	groupNames := lo.Keys(groups)
+	sort.Strings(groupNames)
+
+	return lo.Map(groupNames, func(groupName string, _ int) []*pflag.Flag { return groups[groupName] })
+}
+
+// Describe flags, e.g. "--a (or $NELM_A), --b". If withSources, the set flags are described with
+// the source of their values, e.g. "--a (from $NELM_A)".
+func describeFlags(cmd *cobra.Command, flags []*pflag.Flag, withSources bool) string {
+	var descriptions []string
+	for _, flag := range flags {
+		description := "--" + flag.Name
+
+		if withSources {
+			if !flag.Changed {
+				if envVars := flag.Annotations[FlagEnvVarsAnnotationName]; len(envVars) > 0 {
+					description += fmt.Sprintf(" (or %s)", strings.Join(envVars, ", "))
+				}
+			} else if origin, err := GetFlagValueOrigin(cmd, flag.Name); err == nil {
+				switch origin.Source {
+				case FlagValueSourceEnv:
+					description += fmt.Sprintf(" (from $%s)", strings.Join(origin.EnvVars, ", $"))
+				case FlagValueSourceConfig:
+					description += fmt.Sprintf(" (from config key %q)", origin.ConfigKey)
+				}
+			}
+		}
+
+		descriptions = append(descriptions, description)
+	}
+
+	return strings.Join(descriptions, ", ")
+}
@@ -0,0 +1,49 @@
+package cli
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/cobra"
+
+	"github.com/werf/common-go/pkg/util"
+)
+
+var _ = Describe("ValidateFlagConstraints", func() {
+	var cmd *cobra.Command
+
+	addFlags := func(env util.MapEnvProvider) {
+		registry := newTestRegistry(env)
+		cmd = newTestCommand()
+
+		var kubeConfig, kubeConfigData, username, password, release, chart string
+		Expect(AddFlag(cmd, &kubeConfig, "kube-config", "", "", AddFlagOptions{Registry: registry, MutuallyExclusiveGroups: []string{"kube-config"}})).To(Succeed())
+		Expect(AddFlag(cmd, &kubeConfigData, "kube-config-data", "", "", AddFlagOptions{Registry: registry, MutuallyExclusiveGroups: []string{"kube-config"}})).To(Succeed())
+		Expect(AddFlag(cmd, &username, "username", "", "", AddFlagOptions{Registry: registry, RequiredTogetherGroups: []string{"auth"}})).To(Succeed())
+		Expect(AddFlag(cmd, &password, "password", "", "", AddFlagOptions{Registry: registry, RequiredTogetherGroups: []string{"auth"}})).To(Succeed())
+		Expect(AddFlag(cmd, &release, "release", "", "", AddFlagOptions{Registry: registry, OneRequiredGroups: []string{"target"}})).To(Succeed())
+		Expect(AddFlag(cmd, &chart, "chart", "", "", AddFlagOptions{Registry: registry, OneRequiredGroups: []string{"target"}})).To(Succeed())
+	}
+
+	It("should succeed if the constraints are satisfied", func() {
+		addFlags(util.MapEnvProvider{
+			"TEST_RUN_RELEASE": "app",
+		})
+
+		Expect(cmd.ParseFlags([]string{"--kube-config=/kube", "--username=u", "--password=p"})).To(Succeed())
+		Expect(ValidateFlagConstraints(cmd)).To(Succeed())
+	})
+
+	It("should name flags and env vars in errors", func() {
+		addFlags(util.MapEnvProvider{
+			"TEST_RUN_KUBE_CONFIG_DATA": "YQ==",
+			"TEST_RUN_USERNAME":         "u",
+		})
+
+		Expect(cmd.ParseFlags([]string{"--kube-config=/kube"})).To(Succeed())
+		Expect(NewValidateFlagConstraintsPreRunE()(cmd, nil)).To(MatchError(
+			"flags --kube-config, --kube-config-data are mutually exclusive, but --kube-config, --kube-config-data (from $TEST_RUN_KUBE_CONFIG_DATA) are set\n" +
+				"flags --password, --username must be set together, but --username (from $TEST_RUN_USERNAME) are set and --password (or $TEST_RUN_PASSWORD) are not\n" +
+				"at least one of flags --chart (or $TEST_RUN_CHART), --release (or $TEST_RUN_RELEASE) must be set",
+		))
+	})
+})
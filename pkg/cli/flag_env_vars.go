@@ -2,7 +2,6 @@ package cli
 
 import (
 	"fmt"
-	"os"
 	"regexp"
 	"strings"
 
@@ -12,10 +11,9 @@ import (
 )
 
 var (
+	// Prefix of env vars of the flags in DefaultRegistry.
 	FlagEnvVarsPrefix string
 
-	definedFlagEnvVarRegexes = make(map[FlagRegexExpr]*regexp.Regexp)
-
 	_ GetFlagEnvVarRegexesInterface = GetFlagLocalEnvVarRegexes
 	_ GetFlagEnvVarRegexesInterface = GetFlagGlobalEnvVarRegexes
 	_ GetFlagEnvVarRegexesInterface = GetFlagGlobalAndLocalEnvVarRegexes
@@ -35,60 +33,85 @@ type FlagRegexExpr struct {
 
 type GetFlagEnvVarRegexesInterface func(cmd *cobra.Command, flagName string) ([]*FlagRegexExpr, error)
 
+// GetFlagLocalEnvVarRegexes is Registry.GetFlagLocalEnvVarRegexes of DefaultRegistry.
+func GetFlagLocalEnvVarRegexes(cmd *cobra.Command, flagName string) ([]*FlagRegexExpr, error) {
+	return DefaultRegistry.GetFlagLocalEnvVarRegexes(cmd, flagName)
+}
+
 // Return env var regexp in the form of "^NELM_RELEASE_DEPLOY_AUTO_ROLLBACK$".
 // The format is "^<prefix><command_path>_<flag_name>$".
-func GetFlagLocalEnvVarRegexes(cmd *cobra.Command, flagName string) ([]*FlagRegexExpr, error) {
+func (r *Registry) GetFlagLocalEnvVarRegexes(cmd *cobra.Command, flagName string) ([]*FlagRegexExpr, error) {
 	commandPath := lo.Reverse(strings.SplitN(cmd.CommandPath(), " ", 2))[0]
 
-	base := caps.ToScreamingSnake(fmt.Sprintf("%s%s_%s", FlagEnvVarsPrefix, commandPath, flagName))
+	base := caps.ToScreamingSnake(fmt.Sprintf("%s%s_%s", r.EnvVarsPrefix(), commandPath, flagName))
 	human := "$" + base
 	expr := "^" + base + "$"
 
 	return []*FlagRegexExpr{NewFlagRegexExpr(expr, human)}, nil
 }
 
+// GetFlagLocalMultiEnvVarRegexes is Registry.GetFlagLocalMultiEnvVarRegexes of DefaultRegistry.
+func GetFlagLocalMultiEnvVarRegexes(cmd *cobra.Command, flagName string) ([]*FlagRegexExpr, error) {
+	return DefaultRegistry.GetFlagLocalMultiEnvVarRegexes(cmd, flagName)
+}
+
 // Return env var regexp in the form of "^NELM_RELEASE_DEPLOY_LABELS_.+".
 // The format is "^<prefix><command_path>_<flag_name>_.+".
-func GetFlagLocalMultiEnvVarRegexes(cmd *cobra.Command, flagName string) ([]*FlagRegexExpr, error) {
+func (r *Registry) GetFlagLocalMultiEnvVarRegexes(cmd *cobra.Command, flagName string) ([]*FlagRegexExpr, error) {
 	commandPath := lo.Reverse(strings.SplitN(cmd.CommandPath(), " ", 2))[0]
 
-	base := caps.ToScreamingSnake(fmt.Sprintf("%s%s_%s", FlagEnvVarsPrefix, commandPath, flagName))
+	base := caps.ToScreamingSnake(fmt.Sprintf("%s%s_%s", r.EnvVarsPrefix(), commandPath, flagName))
 	human := "$" + base + "_*"
 	expr := "^" + base + "_.+"
 
 	return []*FlagRegexExpr{NewFlagRegexExpr(expr, human)}, nil
 }
 
+// GetFlagGlobalEnvVarRegexes is Registry.GetFlagGlobalEnvVarRegexes of DefaultRegistry.
+func GetFlagGlobalEnvVarRegexes(cmd *cobra.Command, flagName string) ([]*FlagRegexExpr, error) {
+	return DefaultRegistry.GetFlagGlobalEnvVarRegexes(cmd, flagName)
+}
+
 // Return env var regexp in the form of "^NELM_AUTO_ROLLBACK$".
 // The format is "^<prefix><flag_name>$".
-func GetFlagGlobalEnvVarRegexes(cmd *cobra.Command, flagName string) ([]*FlagRegexExpr, error) {
-	base := caps.ToScreamingSnake(fmt.Sprintf("%s%s", FlagEnvVarsPrefix, flagName))
+func (r *Registry) GetFlagGlobalEnvVarRegexes(cmd *cobra.Command, flagName string) ([]*FlagRegexExpr, error) {
+	base := caps.ToScreamingSnake(fmt.Sprintf("%s%s", r.EnvVarsPrefix(), flagName))
 	human := "$" + base
 	expr := "^" + base + "$"
 
 	return []*FlagRegexExpr{NewFlagRegexExpr(expr, human)}, nil
 }
 
+// GetFlagGlobalMultiEnvVarRegexes is Registry.GetFlagGlobalMultiEnvVarRegexes of DefaultRegistry.
+func GetFlagGlobalMultiEnvVarRegexes(cmd *cobra.Command, flagName string) ([]*FlagRegexExpr, error) {
+	return DefaultRegistry.GetFlagGlobalMultiEnvVarRegexes(cmd, flagName)
+}
+
 // Return env var regexp in the form of "^NELM_LABELS_.+".
 // The format is "^<prefix><flag_name>_.+".
-func GetFlagGlobalMultiEnvVarRegexes(cmd *cobra.Command, flagName string) ([]*FlagRegexExpr, error) {
-	base := caps.ToScreamingSnake(fmt.Sprintf("%s%s", FlagEnvVarsPrefix, flagName))
+func (r *Registry) GetFlagGlobalMultiEnvVarRegexes(cmd *cobra.Command, flagName string) ([]*FlagRegexExpr, error) {
+	base := caps.ToScreamingSnake(fmt.Sprintf("%s%s", r.EnvVarsPrefix(), flagName))
 	human := "$" + base + "_*"
 	expr := "^" + base + "_.+"
 
 	return []*FlagRegexExpr{NewFlagRegexExpr(expr, human)}, nil
 }
 
+// GetFlagGlobalAndLocalEnvVarRegexes is Registry.GetFlagGlobalAndLocalEnvVarRegexes of DefaultRegistry.
+func GetFlagGlobalAndLocalEnvVarRegexes(cmd *cobra.Command, flagName string) ([]*FlagRegexExpr, error) {
+	return DefaultRegistry.GetFlagGlobalAndLocalEnvVarRegexes(cmd, flagName)
+}
+
 // Return env var regexps in the form of "^NELM_AUTO_ROLLBACK$" and
 // "^NELM_RELEASE_DEPLOY_AUTO_ROLLBACK$". The latter has higher priority.
 // The format is "^<prefix><flag_name>$" and "^<prefix><command_path>_<flag_name>$".
-func GetFlagGlobalAndLocalEnvVarRegexes(cmd *cobra.Command, flagName string) ([]*FlagRegexExpr, error) {
-	globalEnvVarRegexes, err := GetFlagGlobalEnvVarRegexes(cmd, flagName)
+func (r *Registry) GetFlagGlobalAndLocalEnvVarRegexes(cmd *cobra.Command, flagName string) ([]*FlagRegexExpr, error) {
+	globalEnvVarRegexes, err := r.GetFlagGlobalEnvVarRegexes(cmd, flagName)
 	if err != nil {
 		return nil, fmt.Errorf("get global env var regexes: %w", err)
 	}
 
-	localEnvVarRegexes, err := GetFlagLocalEnvVarRegexes(cmd, flagName)
+	localEnvVarRegexes, err := r.GetFlagLocalEnvVarRegexes(cmd, flagName)
 	if err != nil {
 		return nil, fmt.Errorf("get local env var regexes: %w", err)
 	}
@@ -96,15 +119,20 @@ func GetFlagGlobalAndLocalEnvVarRegexes(cmd *cobra.Command, flagName string) ([]
 	return append(globalEnvVarRegexes, localEnvVarRegexes...), nil
 }
 
+// GetFlagGlobalAndLocalMultiEnvVarRegexes is Registry.GetFlagGlobalAndLocalMultiEnvVarRegexes of DefaultRegistry.
+func GetFlagGlobalAndLocalMultiEnvVarRegexes(cmd *cobra.Command, flagName string) ([]*FlagRegexExpr, error) {
+	return DefaultRegistry.GetFlagGlobalAndLocalMultiEnvVarRegexes(cmd, flagName)
+}
+
 // Return env var regexps in the form of "^NELM_LABELS_.+" and "^NELM_RELEASE_DEPLOY_LABELS_.+". //
 // The format is "^<prefix><flag_name>_.+" and "^<prefix><command_path>_<flag_name>_.+".
-func GetFlagGlobalAndLocalMultiEnvVarRegexes(cmd *cobra.Command, flagName string) ([]*FlagRegexExpr, error) {
-	globalEnvVarRegexes, err := GetFlagGlobalMultiEnvVarRegexes(cmd, flagName)
+func (r *Registry) GetFlagGlobalAndLocalMultiEnvVarRegexes(cmd *cobra.Command, flagName string) ([]*FlagRegexExpr, error) {
+	globalEnvVarRegexes, err := r.GetFlagGlobalMultiEnvVarRegexes(cmd, flagName)
 	if err != nil {
 		return nil, fmt.Errorf("get global env var regexes: %w", err)
 	}
 
-	localEnvVarRegexes, err := GetFlagLocalMultiEnvVarRegexes(cmd, flagName)
+	localEnvVarRegexes, err := r.GetFlagLocalMultiEnvVarRegexes(cmd, flagName)
 	if err != nil {
 		return nil, fmt.Errorf("get local env var regexes: %w", err)
 	}
@@ -112,15 +140,27 @@ func GetFlagGlobalAndLocalMultiEnvVarRegexes(cmd *cobra.Command, flagName string
 	return append(globalEnvVarRegexes, localEnvVarRegexes...), nil
 }
 
+// GetFlagEnvVarConflicts is Registry.FlagEnvVarConflicts of DefaultRegistry.
+func GetFlagEnvVarConflicts() []FlagEnvVarConflict {
+	return DefaultRegistry.FlagEnvVarConflicts()
+}
+
 func GetDefinedFlagEnvVarRegexes() map[FlagRegexExpr]*regexp.Regexp {
-	return definedFlagEnvVarRegexes
+	return DefaultRegistry.DefinedFlagEnvVarRegexes()
 }
 
 // Get a full list of environment variables that have FlagEnvVarsPrefix as a prefix but were not defined
 // with AddFlag function.
 func FindUndefinedFlagEnvVarsInEnviron() []string {
-	brandedEnvVars := lo.Filter(os.Environ(), func(envVar string, _ int) bool {
-		return strings.HasPrefix(envVar, fmt.Sprintf("%s", FlagEnvVarsPrefix))
+	return DefaultRegistry.FindUndefinedFlagEnvVarsInEnviron()
+}
+
+// Get a full list of environment variables that have the registry env vars prefix as a prefix but
+// were not defined with AddFlag function.
+func (r *Registry) FindUndefinedFlagEnvVarsInEnviron() []string {
+	prefix := r.EnvVarsPrefix()
+	brandedEnvVars := lo.Filter(r.EnvProvider().Environ(), func(envVar string, _ int) bool {
+		return strings.HasPrefix(envVar, prefix)
 	})
 
 	brandedEnvVarNames := lo.Map(brandedEnvVars, func(envVar string, _ int) string {
@@ -128,6 +168,8 @@ func FindUndefinedFlagEnvVarsInEnviron() []string {
 		return envVarName
 	})
 
+	definedFlagEnvVarRegexes := r.DefinedFlagEnvVarRegexes()
+
 	var undefinedEnvVars []string
 envVarsLoop:
 	for _, envVar := range brandedEnvVarNames {
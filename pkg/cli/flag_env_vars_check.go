@@ -0,0 +1,161 @@
+package cli
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+
+	"github.com/werf/common-go/pkg/util"
+)
+
+const maxUndefinedEnvVarSuggestions = 3
+
+type CheckUndefinedFlagEnvVarsOptions struct {
+	// Return an error instead of printing warnings.
+	Strict bool
+	// Names or glob patterns (see path.Match) of prefixed env vars which are not defined by flags
+	// but used otherwise, e.g. "NELM_FEAT_*".
+	AllowedEnvVars []string
+	// Registry of the flags. Defaults to DefaultRegistry.
+	Registry *Registry
+}
+
+// UndefinedFlagEnvVar is an env var with the registry env vars prefix, which doesn't match any
+// flag.
+type UndefinedFlagEnvVar struct {
+	Name string
+	// The closest defined env vars in human-readable form, e.g. "$NELM_RELEASE_NAME", the closest
+	// first.
+	Suggestions []string
+}
+
+func (v UndefinedFlagEnvVar) String() string {
+	if len(v.Suggestions) == 0 {
+		return "$" + v.Name
+	}
+
+	return fmt.Sprintf("$%s (did you mean %s?)", v.Name, strings.Join(v.Suggestions, " or "))
+}
+
+// NewCheckUndefinedFlagEnvVarsPreRunE returns a PersistentPreRunE function which checks for
+// undefined flag env vars, see CheckUndefinedFlagEnvVars.
+func NewCheckUndefinedFlagEnvVarsPreRunE(opts CheckUndefinedFlagEnvVarsOptions) func(cmd *cobra.Command, args []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		return CheckUndefinedFlagEnvVars(cmd, opts)
+	}
+}
+
+// CheckUndefinedFlagEnvVars finds env vars with the registry env vars prefix, which don't match
+// any flag added with AddFlag and are not allowed explicitly, e.g. because of a typo. Prints a
+// warning for each of them to the command stderr or, in the strict mode, returns an error listing
+// all of them.
+func CheckUndefinedFlagEnvVars(cmd *cobra.Command, opts CheckUndefinedFlagEnvVarsOptions) error {
+	registry := registryOrDefault(opts.Registry)
+
+	undefinedEnvVars, err := registry.FindUndefinedFlagEnvVars(opts.AllowedEnvVars)
+	if err != nil {
+		return fmt.Errorf("find undefined flag env vars: %w", err)
+	}
+
+	if len(undefinedEnvVars) == 0 {
+		return nil
+	}
+
+	if opts.Strict {
+		return fmt.Errorf("undefined environment variables with prefix %q: %s", registry.EnvVarsPrefix(), strings.Join(lo.Map(undefinedEnvVars, func(v UndefinedFlagEnvVar, _ int) string {
+			return v.String()
+		}), ", "))
+	}
+
+	for _, undefinedEnvVar := range undefinedEnvVars {
+		if _, err := fmt.Fprintf(cmd.ErrOrStderr(), "Warning: undefined environment variable %s\n", undefinedEnvVar); err != nil {
+			return fmt.Errorf("write warning: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// FindUndefinedFlagEnvVars is Registry.FindUndefinedFlagEnvVars of DefaultRegistry.
+func FindUndefinedFlagEnvVars(allowedEnvVars []string) ([]UndefinedFlagEnvVar, error) {
+	return DefaultRegistry.FindUndefinedFlagEnvVars(allowedEnvVars)
+}
+
+// FindUndefinedFlagEnvVars is like FindUndefinedFlagEnvVarsInEnviron, but skips the allowed env
+// vars (names or glob patterns), sorts the result and suggests the closest defined env vars.
+func (r *Registry) FindUndefinedFlagEnvVars(allowedEnvVars []string) ([]UndefinedFlagEnvVar, error) {
+	envVarNames := r.FindUndefinedFlagEnvVarsInEnviron()
+	sort.Strings(envVarNames)
+
+	var result []UndefinedFlagEnvVar
+envVarsLoop:
+	for _, envVarName := range envVarNames {
+		for _, pattern := range allowedEnvVars {
+			matched, err := path.Match(pattern, envVarName)
+			if err != nil {
+				return nil, fmt.Errorf("match allowed env var pattern %q: %w", pattern, err)
+			}
+
+			if matched {
+				continue envVarsLoop
+			}
+		}
+
+		result = append(result, UndefinedFlagEnvVar{
+			Name:        envVarName,
+			Suggestions: r.suggestFlagEnvVars(envVarName),
+		})
+	}
+
+	return result, nil
+}
+
+func (r *Registry) suggestFlagEnvVars(envVarName string) []string {
+	type suggestion struct {
+		human    string
+		distance int
+	}
+
+	var suggestions []suggestion
+	for regexExpr := range r.DefinedFlagEnvVarRegexes() {
+		name := strings.TrimPrefix(regexExpr.Human, "$")
+
+		// Multi-value env vars, e.g. "$NELM_LABELS_*", are compared without the variable suffix.
+		candidate := envVarName
+		if base, ok := strings.CutSuffix(name, "_*"); ok {
+			name = base
+			if len(candidate) > len(name) {
+				candidate = candidate[:len(name)]
+			}
+		}
+
+		distance := util.LevenshteinDistance(candidate, name)
+		if distance > max(2, len(name)/5) {
+			continue
+		}
+
+		suggestions = append(suggestions, suggestion{human: regexExpr.Human, distance: distance})
+	}
+
+	sort.Slice(suggestions, func(i, j int) bool {
+		if suggestions[i].distance != suggestions[j].distance {
+			return suggestions[i].distance < suggestions[j].distance
+		}
+		return suggestions[i].human < suggestions[j].human
+	})
+
+	var result []string
+	for _, s := range lo.UniqBy(suggestions, func(s suggestion) string { return s.human }) {
+		if len(result) == maxUndefinedEnvVarSuggestions {
+			break
+		}
+
+		result = append(result, s.human)
+	}
+
+	return result
+}
@@ -0,0 +1,51 @@
+package cli
+
+import (
+	"bytes"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/werf/common-go/pkg/util"
+)
+
+var _ = Describe("CheckUndefinedFlagEnvVars", func() {
+	It("should warn or fail on undefined env vars with suggestions", func() {
+		registry := NewRegistry("STRICT_", RegistryOptions{EnvProvider: util.MapEnvProvider{
+			"STRICT_RUN_WORKERS":     "2",
+			"STRICT_RUN_WOKERS":      "2",
+			"STRICT_RUN_LABLES_TEAM": "core",
+			"STRICT_SOMETHING_ELSE":  "1",
+			"STRICT_FEAT_NEW_UI":     "1",
+			"STRICT_TELEMETRY":       "0",
+		}})
+
+		cmd := newTestCommand()
+
+		var (
+			workers int
+			labels  map[string]string
+		)
+		Expect(AddFlag(cmd, &workers, "workers", 1, "", AddFlagOptions{Registry: registry})).To(Succeed())
+		Expect(AddFlag(cmd, &labels, "labels", nil, "", AddFlagOptions{Registry: registry})).To(Succeed())
+
+		opts := CheckUndefinedFlagEnvVarsOptions{AllowedEnvVars: []string{"STRICT_FEAT_*", "STRICT_TELEMETRY"}, Registry: registry}
+
+		var stderr bytes.Buffer
+		cmd.SetErr(&stderr)
+		Expect(CheckUndefinedFlagEnvVars(cmd, opts)).To(Succeed())
+		Expect(stderr.String()).To(Equal(`Warning: undefined environment variable $STRICT_RUN_LABLES_TEAM (did you mean $STRICT_RUN_LABELS_*?)
+Warning: undefined environment variable $STRICT_RUN_WOKERS (did you mean $STRICT_RUN_WORKERS?)
+Warning: undefined environment variable $STRICT_SOMETHING_ELSE
+`))
+
+		opts.Strict = true
+		Expect(NewCheckUndefinedFlagEnvVarsPreRunE(opts)(cmd, nil)).To(MatchError(`undefined environment variables with prefix "STRICT_": $STRICT_RUN_LABLES_TEAM (did you mean $STRICT_RUN_LABELS_*?), $STRICT_RUN_WOKERS (did you mean $STRICT_RUN_WORKERS?), $STRICT_SOMETHING_ELSE`))
+
+		opts.AllowedEnvVars = append(opts.AllowedEnvVars, "STRICT_RUN_*", "STRICT_SOMETHING_ELSE")
+		Expect(CheckUndefinedFlagEnvVars(cmd, opts)).To(Succeed())
+
+		opts.AllowedEnvVars = []string{"["}
+		Expect(CheckUndefinedFlagEnvVars(cmd, opts)).To(MatchError(ContainSubstring("match allowed env var pattern")))
+	})
+})
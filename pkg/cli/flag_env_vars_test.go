@@ -0,0 +1,124 @@
+package cli
+
+import (
+	"bytes"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/werf/common-go/pkg/util"
+)
+
+type envVarRegexesEntry struct {
+	getEnvVarRegexesFunc func(r *Registry) GetFlagEnvVarRegexesInterface
+	env                  util.MapEnvProvider
+	multi                bool
+	expected             interface{}
+	expectedConflict     *FlagEnvVarConflict
+}
+
+var _ = DescribeTable("env var resolution",
+	func(e envVarRegexesEntry) {
+		// Repeated to make sure that the result doesn't depend on the map iteration order.
+		for i := 0; i < 10; i++ {
+			registry := NewRegistry("P_", RegistryOptions{EnvProvider: e.env})
+			cmd := newTestCommand()
+			opts := AddFlagOptions{Registry: registry, GetEnvVarRegexesFunc: e.getEnvVarRegexesFunc(registry)}
+
+			if e.multi {
+				var values []string
+				Expect(AddFlag(cmd, &values, "values", nil, "", opts)).To(Succeed())
+				Expect(values).To(Equal(e.expected))
+			} else {
+				var workers string
+				Expect(AddFlag(cmd, &workers, "workers", "default", "", opts)).To(Succeed())
+				Expect(workers).To(Equal(e.expected))
+			}
+
+			if e.expectedConflict == nil {
+				Expect(registry.FlagEnvVarConflicts()).To(BeEmpty())
+			} else {
+				Expect(registry.FlagEnvVarConflicts()).To(Equal([]FlagEnvVarConflict{*e.expectedConflict}))
+			}
+		}
+	},
+	Entry("local", envVarRegexesEntry{
+		getEnvVarRegexesFunc: func(r *Registry) GetFlagEnvVarRegexesInterface { return r.GetFlagLocalEnvVarRegexes },
+		env:                  util.MapEnvProvider{"P_RUN_WORKERS": "local", "P_WORKERS": "global"},
+		expected:             "local",
+	}),
+	Entry("local, empty value is ignored", envVarRegexesEntry{
+		getEnvVarRegexesFunc: func(r *Registry) GetFlagEnvVarRegexesInterface { return r.GetFlagLocalEnvVarRegexes },
+		env:                  util.MapEnvProvider{"P_RUN_WORKERS": ""},
+		expected:             "default",
+	}),
+	Entry("global", envVarRegexesEntry{
+		getEnvVarRegexesFunc: func(r *Registry) GetFlagEnvVarRegexesInterface { return r.GetFlagGlobalEnvVarRegexes },
+		env:                  util.MapEnvProvider{"P_RUN_WORKERS": "local", "P_WORKERS": "global"},
+		expected:             "global",
+	}),
+	Entry("global and local, only global set", envVarRegexesEntry{
+		getEnvVarRegexesFunc: func(r *Registry) GetFlagEnvVarRegexesInterface { return r.GetFlagGlobalAndLocalEnvVarRegexes },
+		env:                  util.MapEnvProvider{"P_WORKERS": "global"},
+		expected:             "global",
+	}),
+	Entry("global and local, local wins", envVarRegexesEntry{
+		getEnvVarRegexesFunc: func(r *Registry) GetFlagEnvVarRegexesInterface { return r.GetFlagGlobalAndLocalEnvVarRegexes },
+		env:                  util.MapEnvProvider{"P_RUN_WORKERS": "local", "P_WORKERS": "global"},
+		expected:             "local",
+		expectedConflict: &FlagEnvVarConflict{
+			CommandPath:    "test run",
+			FlagName:       "workers",
+			UsedEnvVar:     "P_RUN_WORKERS",
+			IgnoredEnvVars: []string{"P_WORKERS"},
+		},
+	}),
+	Entry("local multi for scalar, lexically last wins", envVarRegexesEntry{
+		getEnvVarRegexesFunc: func(r *Registry) GetFlagEnvVarRegexesInterface { return r.GetFlagLocalMultiEnvVarRegexes },
+		env:                  util.MapEnvProvider{"P_RUN_WORKERS_B": "b", "P_RUN_WORKERS_A": "a", "P_RUN_WORKERS_C": "c"},
+		expected:             "c",
+		expectedConflict: &FlagEnvVarConflict{
+			CommandPath:    "test run",
+			FlagName:       "workers",
+			UsedEnvVar:     "P_RUN_WORKERS_C",
+			IgnoredEnvVars: []string{"P_RUN_WORKERS_A", "P_RUN_WORKERS_B"},
+		},
+	}),
+	Entry("local multi", envVarRegexesEntry{
+		getEnvVarRegexesFunc: func(r *Registry) GetFlagEnvVarRegexesInterface { return r.GetFlagLocalMultiEnvVarRegexes },
+		env:                  util.MapEnvProvider{"P_RUN_VALUES_B": "b", "P_RUN_VALUES_A": "a1,a2", "P_VALUES_X": "x"},
+		multi:                true,
+		expected:             []string{"a1", "a2", "b"},
+	}),
+	Entry("global multi", envVarRegexesEntry{
+		getEnvVarRegexesFunc: func(r *Registry) GetFlagEnvVarRegexesInterface { return r.GetFlagGlobalMultiEnvVarRegexes },
+		env:                  util.MapEnvProvider{"P_RUN_VALUES_B": "b", "P_VALUES_Y": "y", "P_VALUES_X": "x"},
+		multi:                true,
+		expected:             []string{"x", "y"},
+	}),
+	Entry("global and local multi, global first", envVarRegexesEntry{
+		getEnvVarRegexesFunc: func(r *Registry) GetFlagEnvVarRegexesInterface {
+			return r.GetFlagGlobalAndLocalMultiEnvVarRegexes
+		},
+		env:      util.MapEnvProvider{"P_VALUES_Z": "z", "P_VALUES_A": "a", "P_RUN_VALUES_B": "b", "P_RUN_VALUES_A": "ra"},
+		multi:    true,
+		expected: []string{"a", "z", "ra", "b"},
+	}),
+)
+
+var _ = Describe("Registry.WarnFlagEnvVarConflicts", func() {
+	It("should write a warning for each conflict", func() {
+		registry := NewRegistry("P_", RegistryOptions{EnvProvider: util.MapEnvProvider{"P_RUN_WORKERS": "2", "P_WORKERS": "1"}})
+
+		var workers int
+		Expect(AddFlag(newTestCommand(), &workers, "workers", 0, "", AddFlagOptions{
+			Registry:             registry,
+			GetEnvVarRegexesFunc: registry.GetFlagGlobalAndLocalEnvVarRegexes,
+		})).To(Succeed())
+		Expect(workers).To(Equal(2))
+
+		var out bytes.Buffer
+		Expect(registry.WarnFlagEnvVarConflicts(&out)).To(Succeed())
+		Expect(out.String()).To(Equal("Warning: flag --workers of command \"test run\" is set from $P_RUN_WORKERS, ignoring $P_WORKERS\n"))
+	})
+})
@@ -0,0 +1,114 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+const FlagAllowedValuesAnnotationName = "allowed-values"
+
+var (
+	_ pflag.Value      = (*restrictedValue)(nil)
+	_ pflag.SliceValue = (*restrictedSliceValue)(nil)
+)
+
+// Wrap the flag value so that values from both cli args and env vars are validated.
+func restrictFlagValues(cmd *cobra.Command, flagName string, allowedValues []string, validateFunc func(value string) error, splitOnCommas bool) error {
+	flag := cmd.Flags().Lookup(flagName)
+
+	validate := func(value string) error {
+		if len(allowedValues) > 0 && !lo.Contains(allowedValues, value) {
+			return fmt.Errorf("value %q is not allowed, allowed values: %s", value, strings.Join(allowedValues, ", "))
+		}
+
+		if validateFunc != nil {
+			if err := validateFunc(value); err != nil {
+				return fmt.Errorf("value %q is not valid: %w", value, err)
+			}
+		}
+
+		return nil
+	}
+
+	switch value := flag.Value.(type) {
+	case *restrictedValue, *restrictedSliceValue:
+		return fmt.Errorf("flag values are already restricted")
+	case pflag.SliceValue:
+		flag.Value = &restrictedSliceValue{
+			restrictedValue: restrictedValue{Value: flag.Value, validate: validate, splitOnCommas: splitOnCommas},
+			sliceValue:      value,
+		}
+	default:
+		if flag.Value.Type() == "stringToString" {
+			return fmt.Errorf("restricted values are not supported for map flags")
+		}
+
+		flag.Value = &restrictedValue{Value: flag.Value, validate: validate}
+	}
+
+	if len(allowedValues) > 0 {
+		if err := cmd.Flags().SetAnnotation(flagName, FlagAllowedValuesAnnotationName, allowedValues); err != nil {
+			return fmt.Errorf("set allowed values annotation: %w", err)
+		}
+	}
+
+	return nil
+}
+
+type restrictedValue struct {
+	pflag.Value
+
+	validate      func(value string) error
+	splitOnCommas bool
+}
+
+func (v *restrictedValue) Set(s string) error {
+	values := []string{s}
+	if v.splitOnCommas {
+		var err error
+		values, err = splitComma(s)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, value := range values {
+		if err := v.validate(value); err != nil {
+			return err
+		}
+	}
+
+	return v.Value.Set(s)
+}
+
+type restrictedSliceValue struct {
+	restrictedValue
+
+	sliceValue pflag.SliceValue
+}
+
+func (v *restrictedSliceValue) Append(s string) error {
+	if err := v.validate(s); err != nil {
+		return err
+	}
+
+	return v.sliceValue.Append(s)
+}
+
+func (v *restrictedSliceValue) Replace(values []string) error {
+	for _, value := range values {
+		if err := v.validate(value); err != nil {
+			return err
+		}
+	}
+
+	return v.sliceValue.Replace(values)
+}
+
+func (v *restrictedSliceValue) GetSlice() []string {
+	return v.sliceValue.GetSlice()
+}
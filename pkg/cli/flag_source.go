@@ -0,0 +1,257 @@
+package cli
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+var (
+	_ pflag.Value      = (*sourceTrackingValue)(nil)
+	_ pflag.SliceValue = (*sourceTrackingSliceValue)(nil)
+)
+
+const (
+	FlagValueSourceAnnotationName    = "value-source"
+	FlagValueEnvVarsAnnotationName   = "value-env-vars"
+	FlagValueConfigKeyAnnotationName = "value-config-key"
+)
+
+type FlagValueSource string
+
+const (
+	FlagValueSourceDefault FlagValueSource = "default"
+	FlagValueSourceConfig  FlagValueSource = "config"
+	FlagValueSourceEnv     FlagValueSource = "env"
+	FlagValueSourceCLI     FlagValueSource = "cli"
+)
+
+// FlagValueOrigin describes where the effective flag value came from.
+type FlagValueOrigin struct {
+	FlagName string
+	Value    string
+	// The source with the highest priority that provided the value.
+	Source FlagValueSource
+	// Names of env vars which provided the value, if Source is env. Values of multiple env vars
+	// are joined for slice and map flags.
+	EnvVars []string
+	// Config file key (e.g. "deploy.timeout") which provided the value, if Source is config.
+	ConfigKey string
+}
+
+func (o FlagValueOrigin) String() string {
+	result := fmt.Sprintf("--%s=%s (%s", o.FlagName, o.Value, o.Source)
+
+	switch {
+	case o.Source == FlagValueSourceConfig:
+		result += " " + o.ConfigKey
+	case len(o.EnvVars) > 0:
+		result += " $" + strings.Join(o.EnvVars, ", $")
+	}
+
+	return result + ")"
+}
+
+type DumpFlagValuesOptions struct {
+	// Include hidden flags.
+	IncludeHidden bool
+	// Names of flags with sensitive values, which are replaced with "***".
+	SensitiveFlags []string
+}
+
+// GetFlagValueOrigin returns where the value of the flag came from. Should be called after the cli
+// args are parsed, e.g. in PreRunE or RunE.
+func GetFlagValueOrigin(cmd *cobra.Command, flagName string) (FlagValueOrigin, error) {
+	flag := cmd.Flag(flagName)
+	if flag == nil {
+		return FlagValueOrigin{}, fmt.Errorf("flag %q not found", flagName)
+	}
+
+	origin := FlagValueOrigin{
+		FlagName: flag.Name,
+		Value:    formatFlagValue(flag),
+		Source:   FlagValueSourceDefault,
+		EnvVars:  flag.Annotations[FlagValueEnvVarsAnnotationName],
+	}
+
+	if source := flag.Annotations[FlagValueSourceAnnotationName]; len(source) > 0 {
+		origin.Source = FlagValueSource(source[0])
+	}
+
+	if origin.Source == FlagValueSourceConfig {
+		origin.ConfigKey = firstAnnotation(flag, FlagValueConfigKeyAnnotationName)
+	}
+
+	// Flags not added with AddFlag don't track their source, but they can only be set from cli
+	// args, which register the flag as actually set in the flag set.
+	if len(flag.Annotations[FlagValueSourceAnnotationName]) == 0 && isFlagSetInFlagSet(cmd.Flags(), flag.Name) {
+		origin.Source = FlagValueSourceCLI
+	}
+
+	return origin, nil
+}
+
+// DumpFlagValues returns the origins of values of all the flags of the command (including the
+// inherited ones) sorted by the flag name, e.g. for --debug output or support requests.
+func DumpFlagValues(cmd *cobra.Command, opts DumpFlagValuesOptions) ([]FlagValueOrigin, error) {
+	var flagNames []string
+	visit := func(flag *pflag.Flag) {
+		if flag.Hidden && !opts.IncludeHidden {
+			return
+		}
+
+		flagNames = append(flagNames, flag.Name)
+	}
+	cmd.LocalFlags().VisitAll(visit)
+	cmd.InheritedFlags().VisitAll(visit)
+
+	flagNames = lo.Uniq(flagNames)
+	sort.Strings(flagNames)
+
+	var origins []FlagValueOrigin
+	for _, flagName := range flagNames {
+		origin, err := GetFlagValueOrigin(cmd, flagName)
+		if err != nil {
+			return nil, fmt.Errorf("get flag %q value origin: %w", flagName, err)
+		}
+
+		if lo.Contains(opts.SensitiveFlags, flagName) && origin.Value != "" {
+			origin.Value = "***"
+		}
+
+		origins = append(origins, origin)
+	}
+
+	return origins, nil
+}
+
+// WriteFlagValues writes the origins of values of all the flags of the command, one per line.
+func WriteFlagValues(w io.Writer, cmd *cobra.Command, opts DumpFlagValuesOptions) error {
+	origins, err := DumpFlagValues(cmd, opts)
+	if err != nil {
+		return fmt.Errorf("dump flag values: %w", err)
+	}
+
+	for _, origin := range origins {
+		if _, err := fmt.Fprintln(w, origin.String()); err != nil {
+			return fmt.Errorf("write flag value: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Format the flag value as pflag does, but with sorted keys for map flags, since pflag renders
+// them in the map iteration order.
+func formatFlagValue(flag *pflag.Flag) string {
+	return sortFlagMapValue(flag, flag.Value.String())
+}
+
+// Format the flag default value like formatFlagValue.
+func formatFlagDefValue(flag *pflag.Flag) string {
+	return sortFlagMapValue(flag, flag.DefValue)
+}
+
+func sortFlagMapValue(flag *pflag.Flag, value string) string {
+	if flag.Value.Type() != "stringToString" || value == "[]" {
+		return value
+	}
+
+	pairs, err := splitComma(strings.TrimSuffix(strings.TrimPrefix(value, "["), "]"))
+	if err != nil {
+		return value
+	}
+	sort.Strings(pairs)
+
+	var b strings.Builder
+	w := csv.NewWriter(&b)
+	if err := w.Write(pairs); err != nil {
+		return value
+	}
+	w.Flush()
+
+	return "[" + strings.TrimSuffix(b.String(), "\n") + "]"
+}
+
+func isFlagSetInFlagSet(flags *pflag.FlagSet, flagName string) bool {
+	var set bool
+	flags.Visit(func(flag *pflag.Flag) {
+		if flag.Name == flagName {
+			set = true
+		}
+	})
+
+	return set
+}
+
+func saveFlagValueSource(flag *pflag.Flag, source FlagValueSource, envVars []string, configKey string) {
+	if flag.Annotations == nil {
+		flag.Annotations = map[string][]string{}
+	}
+
+	flag.Annotations[FlagValueSourceAnnotationName] = []string{string(source)}
+
+	if len(envVars) > 0 {
+		flag.Annotations[FlagValueEnvVarsAnnotationName] = envVars
+	}
+
+	if configKey != "" {
+		flag.Annotations[FlagValueConfigKeyAnnotationName] = []string{configKey}
+	}
+}
+
+// Wrap the flag value so that setting it from cli args is recorded as the value source.
+func trackFlagValueSource(flag *pflag.Flag) {
+	switch value := flag.Value.(type) {
+	case pflag.SliceValue:
+		flag.Value = &sourceTrackingSliceValue{
+			sourceTrackingValue: sourceTrackingValue{Value: flag.Value, flag: flag},
+			sliceValue:          value,
+		}
+	default:
+		flag.Value = &sourceTrackingValue{Value: flag.Value, flag: flag}
+	}
+}
+
+// Every Set is considered to come from cli args. Env vars and config file values are set with
+// Set too, but their source is saved right after, overriding this one.
+type sourceTrackingValue struct {
+	pflag.Value
+
+	flag *pflag.Flag
+}
+
+func (v *sourceTrackingValue) Set(s string) error {
+	if err := v.Value.Set(s); err != nil {
+		return err
+	}
+
+	saveFlagValueSource(v.flag, FlagValueSourceCLI, nil, "")
+	delete(v.flag.Annotations, FlagValueEnvVarsAnnotationName)
+
+	return nil
+}
+
+type sourceTrackingSliceValue struct {
+	sourceTrackingValue
+
+	sliceValue pflag.SliceValue
+}
+
+func (v *sourceTrackingSliceValue) Append(s string) error {
+	return v.sliceValue.Append(s)
+}
+
+func (v *sourceTrackingSliceValue) Replace(s []string) error {
+	return v.sliceValue.Replace(s)
+}
+
+func (v *sourceTrackingSliceValue) GetSlice() []string {
+	return v.sliceValue.GetSlice()
+}
@@ -0,0 +1,60 @@
+package cli
+
+import (
+	"bytes"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/werf/common-go/pkg/util"
+)
+
+var _ = Describe("DumpFlagValues", func() {
+	It("should report the source of each flag value", func() {
+		registry := newTestRegistry(util.MapEnvProvider{
+			"TEST_RUN_FROM_ENV":     "env",
+			"TEST_RUN_CLI_OVER_ENV": "env",
+			"TEST_RUN_LABELS_A":     "a=1",
+			"TEST_RUN_LABELS_B":     "b=2",
+			"TEST_RUN_VALUES_1":     "env.yaml",
+		})
+
+		cmd := newTestCommand()
+		cmd.Root().PersistentFlags().String("kube-context", "", "")
+
+		var (
+			fromDefault, fromConfig, fromEnv, cliOverEnv, token string
+			labels                                              map[string]string
+			values                                              []string
+		)
+		Expect(AddFlag(cmd, &fromDefault, "from-default", "default", "", AddFlagOptions{Registry: registry})).To(Succeed())
+		Expect(AddFlag(cmd, &fromConfig, "from-config", "default", "", AddFlagOptions{Registry: registry})).To(Succeed())
+		Expect(AddFlag(cmd, &fromEnv, "from-env", "default", "", AddFlagOptions{Registry: registry})).To(Succeed())
+		Expect(AddFlag(cmd, &cliOverEnv, "cli-over-env", "default", "", AddFlagOptions{Registry: registry})).To(Succeed())
+		Expect(AddFlag(cmd, &token, "token", "", "", AddFlagOptions{Registry: registry})).To(Succeed())
+		Expect(AddFlag(cmd, &labels, "labels", nil, "", AddFlagOptions{Registry: registry})).To(Succeed())
+		Expect(AddFlag(cmd, &values, "values", nil, "", AddFlagOptions{Registry: registry})).To(Succeed())
+
+		Expect(cmd.ParseFlags([]string{"--cli-over-env=cli", "--values=cli.yaml", "--token=secret", "--kube-context=prod"})).To(Succeed())
+		Expect(ApplyConfig(cmd, []byte("run:\n  from-config: config\n"), ConfigFileOptions{})).To(Succeed())
+
+		origin, err := GetFlagValueOrigin(cmd, "from-env")
+		Expect(err).To(Succeed())
+		Expect(origin).To(Equal(FlagValueOrigin{FlagName: "from-env", Value: "env", Source: FlagValueSourceEnv, EnvVars: []string{"TEST_RUN_FROM_ENV"}}))
+
+		_, err = GetFlagValueOrigin(cmd, "missing")
+		Expect(err).To(MatchError(ContainSubstring(`flag "missing" not found`)))
+
+		var out bytes.Buffer
+		Expect(WriteFlagValues(&out, cmd, DumpFlagValuesOptions{SensitiveFlags: []string{"token"}})).To(Succeed())
+		Expect(out.String()).To(Equal(`--cli-over-env=cli (cli)
+--from-config=config (config run.from-config)
+--from-default=default (default)
+--from-env=env (env $TEST_RUN_FROM_ENV)
+--kube-context=prod (cli)
+--labels=[a=1,b=2] (env $TEST_RUN_LABELS_A, $TEST_RUN_LABELS_B)
+--token=*** (cli)
+--values=[cli.yaml] (cli)
+`))
+	})
+})
@@ -0,0 +1,329 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/cobra"
+
+	"github.com/werf/common-go/pkg/util"
+)
+
+func newTestCommand() *cobra.Command {
+	rootCmd := NewRootCommand(context.Background(), "test", "")
+	cmd := NewSubCommand(context.Background(), "run", "", "", 0, NewCommandGroup("main", "Main", 0), SubCommandOptions{}, func(cmd *cobra.Command, args []string) error {
+		return nil
+	})
+	rootCmd.AddCommand(cmd)
+
+	return cmd
+}
+
+// Tests use their own registries with env vars from the map, so that they don't depend on the
+// process env, FlagEnvVarsPrefix and each other.
+func newTestRegistry(env util.MapEnvProvider) *Registry {
+	return NewRegistry("TEST_", RegistryOptions{EnvProvider: env})
+}
+
+var _ = Describe("AddFlag", func() {
+	It("should support scalar types with values from env vars and cli args", func() {
+		registry := newTestRegistry(util.MapEnvProvider{
+			"TEST_RUN_OFFSET":  "-9000000000",
+			"TEST_RUN_WORKERS": "42",
+			"TEST_RUN_RATIO":   "0.5",
+			"TEST_RUN_IP":      "10.0.0.1",
+			"TEST_RUN_URL":     "https://example.com/path",
+			"TEST_RUN_SIZE":    "512Mi",
+			"TEST_RUN_CPU":     "500m",
+		})
+
+		cmd := newTestCommand()
+
+		var (
+			int64Val   int64
+			uintVal    uint
+			float64Val float64
+			ipVal      net.IP
+			urlVal     *url.URL
+			sizeVal    ByteSize
+			cpuVal     Quantity
+			timeoutVal time.Duration
+		)
+		Expect(AddFlag(cmd, &int64Val, "offset", 0, "Offset", AddFlagOptions{Registry: registry})).To(Succeed())
+		Expect(AddFlag(cmd, &uintVal, "workers", 0, "Workers", AddFlagOptions{Registry: registry})).To(Succeed())
+		Expect(AddFlag(cmd, &float64Val, "ratio", 0, "Ratio", AddFlagOptions{Registry: registry})).To(Succeed())
+		Expect(AddFlag(cmd, &ipVal, "ip", nil, "IP", AddFlagOptions{Registry: registry})).To(Succeed())
+		Expect(AddFlag(cmd, &urlVal, "url", nil, "URL", AddFlagOptions{Registry: registry})).To(Succeed())
+		Expect(AddFlag(cmd, &sizeVal, "size", 0, "Size", AddFlagOptions{Registry: registry})).To(Succeed())
+		Expect(AddFlag(cmd, &cpuVal, "cpu", 1, "CPU", AddFlagOptions{Registry: registry})).To(Succeed())
+		Expect(AddFlag(cmd, &timeoutVal, "timeout", time.Minute, "Timeout", AddFlagOptions{Registry: registry})).To(Succeed())
+
+		Expect(int64Val).To(Equal(int64(-9000000000)))
+		Expect(uintVal).To(Equal(uint(42)))
+		Expect(float64Val).To(Equal(0.5))
+		Expect(ipVal.String()).To(Equal("10.0.0.1"))
+		Expect(urlVal.Host).To(Equal("example.com"))
+		Expect(sizeVal).To(Equal(ByteSize(512 * 1024 * 1024)))
+		Expect(cpuVal).To(Equal(Quantity(0.5)))
+		Expect(timeoutVal).To(Equal(time.Minute))
+
+		Expect(cmd.ParseFlags([]string{"--size=10GB", "--cpu=2", "--url=http://localhost:8080"})).To(Succeed())
+
+		Expect(sizeVal).To(Equal(ByteSize(10_000_000_000)))
+		Expect(cpuVal).To(Equal(Quantity(2)))
+		Expect(urlVal.Port()).To(Equal("8080"))
+		Expect(cmd.Flag("size").DefValue).To(Equal("0"))
+		Expect(cmd.Flag("cpu").DefValue).To(Equal("1"))
+	})
+
+	It("should join slice values from multiple env vars", func() {
+		registry := newTestRegistry(util.MapEnvProvider{
+			"TEST_RUN_PORTS_1":   "80,443",
+			"TEST_RUN_PORTS_2":   "8080",
+			"TEST_RUN_TOGGLES_A": "true,false",
+		})
+
+		cmd := newTestCommand()
+
+		var ports []int
+		var toggles []bool
+		Expect(AddFlag(cmd, &ports, "ports", nil, "Ports", AddFlagOptions{Registry: registry})).To(Succeed())
+		Expect(AddFlag(cmd, &toggles, "toggles", nil, "Toggles", AddFlagOptions{Registry: registry})).To(Succeed())
+
+		Expect(ports).To(ConsistOf(80, 443, 8080))
+		Expect(toggles).To(Equal([]bool{true, false}))
+	})
+
+	It("should parse int lists with spaces the same way as util.GetIntListEnvVar", func() {
+		registry := newTestRegistry(util.MapEnvProvider{
+			"TEST_RUN_PORTS_1": "80, 443",
+		})
+
+		cmd := newTestCommand()
+
+		var ports []int
+		Expect(AddFlag(cmd, &ports, "ports", nil, "Ports", AddFlagOptions{Registry: registry})).To(Succeed())
+		Expect(ports).To(Equal([]int{80, 443}))
+
+		Expect(cmd.ParseFlags([]string{"--ports=1, 2", "--ports", " 3"})).To(Succeed())
+		Expect(ports).To(Equal([]int{1, 2, 3}))
+		Expect(cmd.ParseFlags([]string{"--ports=4,x"})).To(MatchError(ContainSubstring(`invalid int list: bad element 2 "x"`)))
+	})
+
+	It("should parse percentages", func() {
+		registry := newTestRegistry(util.MapEnvProvider{
+			"TEST_RUN_MAX_UNAVAILABLE": "25%",
+		})
+
+		cmd := newTestCommand()
+
+		var maxUnavailable, maxSurge Percentage
+		Expect(AddFlag(cmd, &maxUnavailable, "max-unavailable", 0, "Max unavailable", AddFlagOptions{Registry: registry})).To(Succeed())
+		Expect(AddFlag(cmd, &maxSurge, "max-surge", 10, "Max surge", AddFlagOptions{Registry: registry})).To(Succeed())
+
+		Expect(maxUnavailable).To(Equal(Percentage(25)))
+		Expect(cmd.Flag("max-surge").DefValue).To(Equal("10%"))
+
+		Expect(cmd.ParseFlags([]string{"--max-surge=12.5"})).To(Succeed())
+		Expect(maxSurge).To(Equal(Percentage(12.5)))
+		Expect(cmd.ParseFlags([]string{"--max-surge=150%"})).To(MatchError(ContainSubstring(`invalid argument "150%" for "--max-surge" flag: invalid percentage: must be from 0 to 100`)))
+	})
+
+	It("should name the env var with an invalid value", func() {
+		registry := newTestRegistry(util.MapEnvProvider{
+			"TEST_RUN_SIZE": "10 parsecs",
+		})
+
+		var sizeVal ByteSize
+		err := AddFlag(newTestCommand(), &sizeVal, "size", 0, "Size", AddFlagOptions{Registry: registry})
+		Expect(err).To(MatchError(ContainSubstring(`environment variable "TEST_RUN_SIZE" value "10 parsecs" is not valid`)))
+	})
+})
+
+var _ = Describe("AddFlag with restricted values", func() {
+	It("should validate values from cli args", func() {
+		cmd := newTestCommand()
+		registry := newTestRegistry(nil)
+
+		var strategy string
+		Expect(AddFlag(cmd, &strategy, "strategy", "rolling", "Deploy strategy", AddFlagOptions{
+			Registry:      registry,
+			AllowedValues: []string{"rolling", "recreate"},
+		})).To(Succeed())
+
+		Expect(cmd.Flag("strategy").Usage).To(Equal("Deploy strategy. Allowed values: rolling, recreate. Var: $TEST_RUN_STRATEGY"))
+
+		Expect(cmd.ParseFlags([]string{"--strategy=recreate"})).To(Succeed())
+		Expect(strategy).To(Equal("recreate"))
+
+		Expect(cmd.ParseFlags([]string{"--strategy=canary"})).To(MatchError(ContainSubstring(`value "canary" is not allowed, allowed values: rolling, recreate`)))
+	})
+
+	It("should validate values from env vars", func() {
+		registry := newTestRegistry(util.MapEnvProvider{
+			"TEST_RUN_STRATEGY": "canary",
+		})
+
+		var strategy string
+		err := AddFlag(newTestCommand(), &strategy, "strategy", "rolling", "Deploy strategy", AddFlagOptions{
+			Registry:      registry,
+			AllowedValues: []string{"rolling", "recreate"},
+		})
+		Expect(err).To(MatchError(ContainSubstring(`environment variable "TEST_RUN_STRATEGY" value "canary" is not valid: value "canary" is not allowed`)))
+	})
+
+	It("should validate each element of slice values", func() {
+		registry := newTestRegistry(util.MapEnvProvider{
+			"TEST_RUN_LEVELS_1": "info,warn",
+		})
+
+		cmd := newTestCommand()
+
+		var levels []string
+		Expect(AddFlag(cmd, &levels, "levels", nil, "Levels", AddFlagOptions{
+			Registry:      registry,
+			AllowedValues: []string{"info", "warn", "error"},
+		})).To(Succeed())
+		Expect(levels).To(Equal([]string{"info", "warn"}))
+
+		Expect(cmd.ParseFlags([]string{"--levels=error,debug"})).To(MatchError(ContainSubstring(`value "debug" is not allowed`)))
+	})
+
+	It("should validate values with the validation func", func() {
+		cmd := newTestCommand()
+		registry := newTestRegistry(nil)
+
+		var port int
+		Expect(AddFlag(cmd, &port, "port", 8080, "Port", AddFlagOptions{
+			Registry: registry,
+			ValidateValue: func(value string) error {
+				if strings.HasPrefix(value, "-") {
+					return fmt.Errorf("must not be negative")
+				}
+				return nil
+			},
+		})).To(Succeed())
+
+		Expect(cmd.ParseFlags([]string{"--port=-1"})).To(MatchError(ContainSubstring(`value "-1" is not valid: must not be negative`)))
+	})
+
+	It("should complete allowed values", func() {
+		cmd := newTestCommand()
+		registry := newTestRegistry(nil)
+
+		var strategy string
+		Expect(AddFlag(cmd, &strategy, "strategy", "rolling", "Deploy strategy", AddFlagOptions{
+			Registry:      registry,
+			AllowedValues: []string{"rolling", "recreate"},
+		})).To(Succeed())
+
+		completionFunc, ok := cmd.GetFlagCompletionFunc("strategy")
+		Expect(ok).To(BeTrue())
+
+		completions, directive := completionFunc(cmd, nil, "")
+		Expect(completions).To(Equal([]string{"rolling", "recreate"}))
+		Expect(directive).To(Equal(cobra.ShellCompDirectiveNoFileComp))
+	})
+})
+
+type testLogLevel string
+
+func (l *testLogLevel) Set(s string) error {
+	switch s {
+	case "debug", "info", "error":
+		*l = testLogLevel(s)
+		return nil
+	default:
+		return fmt.Errorf("unknown log level %q", s)
+	}
+}
+
+func (l *testLogLevel) String() string { return string(*l) }
+
+func (l *testLogLevel) Type() string { return "logLevel" }
+
+type testImageRefs []string
+
+func (r *testImageRefs) Set(s string) error {
+	parts, err := splitComma(s)
+	if err != nil {
+		return err
+	}
+
+	*r = append(*r, parts...)
+
+	return nil
+}
+
+func (r *testImageRefs) String() string { return "[" + strings.Join(*r, ",") + "]" }
+
+func (r *testImageRefs) Type() string { return "imageRefs" }
+
+func (r *testImageRefs) Append(s string) error {
+	*r = append(*r, s)
+	return nil
+}
+
+func (r *testImageRefs) Replace(values []string) error {
+	*r = values
+	return nil
+}
+
+func (r *testImageRefs) GetSlice() []string { return *r }
+
+var _ = Describe("AddFlag with custom values", func() {
+	It("should support pflag.Value destinations", func() {
+		registry := newTestRegistry(util.MapEnvProvider{
+			"TEST_RUN_LOG_LEVEL": "debug",
+		})
+
+		cmd := newTestCommand()
+
+		var logLevel testLogLevel
+		Expect(AddFlag(cmd, &logLevel, "log-level", "info", "Log level", AddFlagOptions{
+			Registry: registry,
+			Group:    NewFlagGroup("logging", "Logging", 10),
+			Required: true,
+		})).To(Succeed())
+
+		Expect(logLevel).To(Equal(testLogLevel("debug")))
+		Expect(cmd.Flag("log-level").DefValue).To(Equal("info"))
+		Expect(cmd.Flag("log-level").Usage).To(Equal("Log level. Var: $TEST_RUN_LOG_LEVEL"))
+		Expect(cmd.Flag("log-level").Annotations).To(HaveKey(FlagGroupIDAnnotationName))
+
+		Expect(cmd.ParseFlags([]string{"--log-level=error"})).To(Succeed())
+		Expect(logLevel).To(Equal(testLogLevel("error")))
+
+		Expect(cmd.ParseFlags([]string{"--log-level=trace"})).To(MatchError(ContainSubstring(`unknown log level "trace"`)))
+	})
+
+	It("should report invalid pflag.Value values from env vars", func() {
+		registry := newTestRegistry(util.MapEnvProvider{
+			"TEST_RUN_LOG_LEVEL": "trace",
+		})
+
+		var logLevel testLogLevel
+		Expect(AddFlag(newTestCommand(), &logLevel, "log-level", "info", "Log level", AddFlagOptions{Registry: registry})).To(MatchError(ContainSubstring(`environment variable "TEST_RUN_LOG_LEVEL" value "trace" is not valid`)))
+	})
+
+	It("should support pflag.SliceValue destinations with multiple env vars", func() {
+		registry := newTestRegistry(util.MapEnvProvider{
+			"TEST_RUN_IMAGES_BACKEND":  "backend:1,backend:2",
+			"TEST_RUN_IMAGES_FRONTEND": "frontend:1",
+		})
+
+		cmd := newTestCommand()
+
+		var images testImageRefs
+		Expect(AddFlag(cmd, &images, "images", nil, "Images", AddFlagOptions{Registry: registry})).To(Succeed())
+
+		Expect(images).To(ConsistOf("backend:1", "backend:2", "frontend:1"))
+		Expect(cmd.Flag("images").Usage).To(Equal("Images. Var: $TEST_RUN_IMAGES_*"))
+	})
+})
@@ -0,0 +1,206 @@
+package cli
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/pflag"
+
+	"github.com/werf/common-go/pkg/util"
+)
+
+var (
+	_ pflag.Value = (*byteSizeValue)(nil)
+	_ pflag.Value = (*quantityValue)(nil)
+	_ pflag.Value = (*percentageValue)(nil)
+	_ pflag.Value = (*urlValue)(nil)
+
+	_ pflag.SliceValue = (*intListValue)(nil)
+)
+
+// ByteSize is a flag type for sizes in bytes, which can be specified with a decimal ("500MB") or
+// binary ("512Mi") unit suffix.
+type ByteSize int64
+
+// Quantity is a flag type for resource quantities in the Kubernetes notation, e.g. "500m" or "1.5Gi".
+type Quantity float64
+
+// Percentage is a flag type for percentages from 0 to 100, which can be specified with or without
+// the "%" suffix, e.g. "25%" or "12.5".
+type Percentage float64
+
+type byteSizeValue struct {
+	dest *ByteSize
+}
+
+func (v *byteSizeValue) Set(s string) error {
+	size, err := util.ParseByteSize(s)
+	if err != nil {
+		return err
+	}
+
+	*v.dest = ByteSize(size)
+
+	return nil
+}
+
+func (v *byteSizeValue) String() string {
+	if v.dest == nil {
+		return ""
+	}
+
+	return util.FormatByteSize(int64(*v.dest))
+}
+
+func (v *byteSizeValue) Type() string {
+	return "byteSize"
+}
+
+type quantityValue struct {
+	dest *Quantity
+}
+
+func (v *quantityValue) Set(s string) error {
+	quantity, err := util.ParseQuantity(s)
+	if err != nil {
+		return err
+	}
+
+	*v.dest = Quantity(quantity)
+
+	return nil
+}
+
+func (v *quantityValue) String() string {
+	if v.dest == nil {
+		return ""
+	}
+
+	return strconv.FormatFloat(float64(*v.dest), 'g', -1, 64)
+}
+
+func (v *quantityValue) Type() string {
+	return "quantity"
+}
+
+type percentageValue struct {
+	dest *Percentage
+}
+
+func (v *percentageValue) Set(s string) error {
+	percentage, err := util.ParsePercentage(s)
+	if err != nil {
+		return err
+	}
+
+	*v.dest = Percentage(percentage)
+
+	return nil
+}
+
+func (v *percentageValue) String() string {
+	if v.dest == nil {
+		return ""
+	}
+
+	return util.FormatPercentage(float64(*v.dest))
+}
+
+func (v *percentageValue) Type() string {
+	return "percentage"
+}
+
+// Replaces the pflag int slice value to parse values with util.ParseIntList, the same way as
+// util.GetIntListEnvVar does.
+type intListValue struct {
+	dest    *[]int
+	changed bool
+}
+
+func (v *intListValue) Set(s string) error {
+	list, err := util.ParseIntList(s)
+	if err != nil {
+		return err
+	}
+
+	// The first value replaces the default, the next ones are appended.
+	if v.changed {
+		*v.dest = append(*v.dest, list...)
+	} else {
+		*v.dest = list
+	}
+	v.changed = true
+
+	return nil
+}
+
+func (v *intListValue) String() string {
+	if v.dest == nil {
+		return "[]"
+	}
+
+	return "[" + strings.Join(v.GetSlice(), ",") + "]"
+}
+
+func (v *intListValue) Type() string {
+	return "intSlice"
+}
+
+func (v *intListValue) Append(s string) error {
+	list, err := util.ParseIntList(s)
+	if err != nil {
+		return err
+	}
+
+	*v.dest = append(*v.dest, list...)
+
+	return nil
+}
+
+func (v *intListValue) Replace(values []string) error {
+	list, err := util.ParseIntList(strings.Join(values, ","))
+	if err != nil {
+		return err
+	}
+
+	*v.dest = list
+
+	return nil
+}
+
+func (v *intListValue) GetSlice() []string {
+	result := make([]string, 0, len(*v.dest))
+	for _, number := range *v.dest {
+		result = append(result, strconv.Itoa(number))
+	}
+
+	return result
+}
+
+type urlValue struct {
+	dest **url.URL
+}
+
+func (v *urlValue) Set(s string) error {
+	u, err := url.Parse(s)
+	if err != nil {
+		return err
+	}
+
+	*v.dest = u
+
+	return nil
+}
+
+func (v *urlValue) String() string {
+	if v.dest == nil || *v.dest == nil {
+		return ""
+	}
+
+	return (*v.dest).String()
+}
+
+func (v *urlValue) Type() string {
+	return "url"
+}
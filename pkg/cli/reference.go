@@ -0,0 +1,292 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"sigs.k8s.io/yaml"
+)
+
+// CommandReference describes a command with its flags and subcommands for reference docs.
+type CommandReference struct {
+	// Full command path, e.g. "nelm release list".
+	Path           string                  `json:"path"`
+	Usage          string                  `json:"usage"`
+	Short          string                  `json:"short,omitempty"`
+	Long           string                  `json:"long,omitempty"`
+	Aliases        []string                `json:"aliases,omitempty"`
+	Examples       []CommandExample        `json:"examples,omitempty"`
+	FlagGroups     []FlagGroupReference    `json:"flagGroups,omitempty"`
+	InheritedFlags []FlagReference         `json:"inheritedFlags,omitempty"`
+	CommandGroups  []CommandGroupReference `json:"commandGroups,omitempty"`
+	// Related commands (full command paths) and links.
+	SeeAlso []string `json:"seeAlso,omitempty"`
+}
+
+type CommandGroupReference struct {
+	Title    string             `json:"title"`
+	Commands []CommandReference `json:"commands"`
+}
+
+type FlagGroupReference struct {
+	Title string          `json:"title"`
+	Flags []FlagReference `json:"flags"`
+}
+
+type FlagReference struct {
+	Name        string `json:"name"`
+	Shorthand   string `json:"shorthand,omitempty"`
+	Type        string `json:"type"`
+	Description string `json:"description,omitempty"`
+	// Empty if the default is the zero value.
+	Default       string   `json:"default,omitempty"`
+	EnvVars       []string `json:"envVars,omitempty"`
+	AllowedValues []string `json:"allowedValues,omitempty"`
+	// Example values, e.g. "app=web".
+	Examples   []string `json:"examples,omitempty"`
+	Required   bool     `json:"required,omitempty"`
+	Deprecated bool     `json:"deprecated,omitempty"`
+	// Deprecation message, if deprecated.
+	DeprecationMessage string `json:"deprecationMessage,omitempty"`
+	// Old names of the flag and its env vars, e.g. "--old-name" or "$NELM_OLD_NAME".
+	DeprecatedAliases []string `json:"deprecatedAliases,omitempty"`
+}
+
+// BuildCommandReference describes the command tree starting from the command. Hidden commands and
+// flags are skipped. Commands and flags are grouped and sorted the same way as in RenderUsage.
+func BuildCommandReference(cmd *cobra.Command) CommandReference {
+	ref := CommandReference{
+		Path:     cmd.CommandPath(),
+		Usage:    cmd.UseLine(),
+		Short:    cmd.Short,
+		Long:     strings.TrimSpace(cmd.Long),
+		Aliases:  cmd.Aliases,
+		Examples: GetCommandExamples(cmd),
+		SeeAlso:  GetCommandSeeAlso(cmd),
+	}
+
+	for _, group := range buildFlagGroups(cmd.LocalFlags(), localFlagsGroupTitle, true) {
+		ref.FlagGroups = append(ref.FlagGroups, FlagGroupReference{
+			Title: group.title,
+			Flags: buildFlagReferences(group.flags),
+		})
+	}
+
+	var inheritedFlags []*pflag.Flag
+	cmd.InheritedFlags().VisitAll(func(flag *pflag.Flag) {
+		if !flag.Hidden {
+			inheritedFlags = append(inheritedFlags, flag)
+		}
+	})
+	ref.InheritedFlags = buildFlagReferences(inheritedFlags)
+
+	for _, group := range buildCommandGroups(cmd) {
+		groupRef := CommandGroupReference{Title: group.title}
+		for _, subCmd := range group.commands {
+			groupRef.Commands = append(groupRef.Commands, BuildCommandReference(subCmd))
+		}
+
+		ref.CommandGroups = append(ref.CommandGroups, groupRef)
+	}
+
+	return ref
+}
+
+func buildFlagReferences(flags []*pflag.Flag) []FlagReference {
+	var refs []FlagReference
+	for _, flag := range flags {
+		ref := FlagReference{
+			Name:               flag.Name,
+			Shorthand:          flag.Shorthand,
+			Type:               flag.Value.Type(),
+			Description:        flag.Usage,
+			EnvVars:            flag.Annotations[FlagEnvVarsAnnotationName],
+			AllowedValues:      flag.Annotations[FlagAllowedValuesAnnotationName],
+			Examples:           flag.Annotations[FlagExamplesAnnotationName],
+			Required:           firstAnnotation(flag, cobra.BashCompOneRequiredFlag) == "true",
+			Deprecated:         flag.Deprecated != "",
+			DeprecationMessage: flag.Deprecated,
+			DeprecatedAliases:  flag.Annotations[FlagDeprecatedAliasesAnnotationName],
+		}
+
+		if description := firstAnnotation(flag, FlagDescriptionAnnotationName); description != "" {
+			ref.Description = description
+		}
+
+		if !isZeroFlagDefault(flag) {
+			ref.Default = formatFlagDefValue(flag)
+		}
+
+		refs = append(refs, ref)
+	}
+
+	return refs
+}
+
+// GenerateJSONReference writes the reference of the command tree as JSON.
+func GenerateJSONReference(w io.Writer, cmd *cobra.Command) error {
+	data, err := json.MarshalIndent(BuildCommandReference(cmd), "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal reference: %w", err)
+	}
+
+	if _, err := w.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("write reference: %w", err)
+	}
+
+	return nil
+}
+
+// GenerateYAMLReference writes the reference of the command tree as YAML.
+func GenerateYAMLReference(w io.Writer, cmd *cobra.Command) error {
+	data, err := yaml.Marshal(BuildCommandReference(cmd))
+	if err != nil {
+		return fmt.Errorf("marshal reference: %w", err)
+	}
+
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("write reference: %w", err)
+	}
+
+	return nil
+}
+
+// GenerateMarkdownReference writes the reference of the command tree as a single Markdown
+// document with a section per command.
+func GenerateMarkdownReference(w io.Writer, cmd *cobra.Command) error {
+	var b strings.Builder
+	writeMarkdownCommandReference(&b, BuildCommandReference(cmd), 1)
+
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return fmt.Errorf("write reference: %w", err)
+	}
+
+	return nil
+}
+
+func writeMarkdownCommandReference(b *strings.Builder, ref CommandReference, level int) {
+	heading := strings.Repeat("#", min(level, 6))
+	subHeading := strings.Repeat("#", min(level+1, 6))
+
+	fmt.Fprintf(b, "%s %s\n\n", heading, ref.Path)
+
+	if description := lo.Ternary(ref.Long != "", ref.Long, ref.Short); description != "" {
+		fmt.Fprintf(b, "%s\n\n", description)
+	}
+
+	fmt.Fprintf(b, "```\n%s\n```\n\n", ref.Usage)
+
+	if len(ref.Aliases) > 0 {
+		fmt.Fprintf(b, "Aliases: %s.\n\n", "`"+strings.Join(ref.Aliases, "`, `")+"`")
+	}
+
+	if len(ref.Examples) > 0 {
+		fmt.Fprintf(b, "%s Examples\n\n", subHeading)
+		for _, example := range ref.Examples {
+			if example.Description != "" {
+				fmt.Fprintf(b, "%s:\n\n", strings.TrimSuffix(strings.TrimSpace(example.Description), "."))
+			}
+			fmt.Fprintf(b, "```shell\n%s\n```\n\n", strings.TrimSpace(example.Command))
+		}
+	}
+
+	for _, group := range ref.CommandGroups {
+		fmt.Fprintf(b, "%s %s\n\n", subHeading, group.Title)
+		b.WriteString("| Command | Description |\n|---|---|\n")
+		for _, subRef := range group.Commands {
+			fmt.Fprintf(b, "| [`%s`](#%s) | %s |\n", subRef.Path, markdownAnchor(subRef.Path), escapeMarkdownTableCell(subRef.Short))
+		}
+		b.WriteString("\n")
+	}
+
+	for _, group := range ref.FlagGroups {
+		fmt.Fprintf(b, "%s %s\n\n", subHeading, group.Title)
+		writeMarkdownFlagsTable(b, group.Flags)
+	}
+
+	if len(ref.InheritedFlags) > 0 {
+		fmt.Fprintf(b, "%s %s\n\n", subHeading, inheritedFlagsTitle)
+		writeMarkdownFlagsTable(b, ref.InheritedFlags)
+	}
+
+	if len(ref.SeeAlso) > 0 {
+		fmt.Fprintf(b, "%s See also\n\n", subHeading)
+		for _, entry := range ref.SeeAlso {
+			if isURL(entry) {
+				fmt.Fprintf(b, "- <%s>\n", entry)
+			} else {
+				fmt.Fprintf(b, "- [`%s`](#%s)\n", entry, markdownAnchor(entry))
+			}
+		}
+		b.WriteString("\n")
+	}
+
+	for _, group := range ref.CommandGroups {
+		for _, subRef := range group.Commands {
+			writeMarkdownCommandReference(b, subRef, level+1)
+		}
+	}
+}
+
+func writeMarkdownFlagsTable(b *strings.Builder, flags []FlagReference) {
+	b.WriteString("| Flag | Type | Default | Env vars | Description |\n|---|---|---|---|---|\n")
+
+	for _, flag := range flags {
+		name := "`--" + flag.Name + "`"
+		if flag.Shorthand != "" {
+			name = "`-" + flag.Shorthand + "`, " + name
+		}
+
+		var defaultValue string
+		if flag.Default != "" {
+			defaultValue = "`" + flag.Default + "`"
+		}
+
+		var envVars []string
+		for _, envVar := range flag.EnvVars {
+			envVars = append(envVars, "`"+envVar+"`")
+		}
+
+		description := flag.Description
+		if len(flag.AllowedValues) > 0 {
+			description += " Allowed values: `" + strings.Join(flag.AllowedValues, "`, `") + "`."
+		}
+		if len(flag.Examples) > 0 {
+			description += " " + lo.Ternary(len(flag.Examples) == 1, "Example", "Examples") + ": `" + strings.Join(formatFlagExamples(flag.Name, flag.Examples), "`, `") + "`."
+		}
+		if flag.Required {
+			description += " **Required.**"
+		}
+		if flag.Deprecated {
+			description += " **Deprecated:** " + flag.DeprecationMessage
+		}
+		if len(flag.DeprecatedAliases) > 0 {
+			description += " Deprecated aliases: `" + strings.Join(flag.DeprecatedAliases, "`, `") + "`."
+		}
+
+		fmt.Fprintf(b, "| %s | %s | %s | %s | %s |\n",
+			name,
+			flag.Type,
+			escapeMarkdownTableCell(defaultValue),
+			strings.Join(envVars, ", "),
+			escapeMarkdownTableCell(strings.TrimSpace(description)),
+		)
+	}
+
+	b.WriteString("\n")
+}
+
+// Anchor of a heading as generated by GitHub and most static site generators.
+func markdownAnchor(heading string) string {
+	return strings.ReplaceAll(strings.ToLower(heading), " ", "-")
+}
+
+func escapeMarkdownTableCell(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.ReplaceAll(s, "\n", "<br>")
+}
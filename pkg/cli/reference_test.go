@@ -0,0 +1,45 @@
+package cli
+
+import (
+	"bytes"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("GenerateReference", func() {
+	var buf bytes.Buffer
+
+	BeforeEach(func() {
+		buf.Reset()
+	})
+
+	It("should generate Markdown reference", func() {
+		rootCmd, deployCmd := newTestCommandTree()
+		Expect(deployCmd.MarkFlagRequired("namespace")).To(Succeed())
+
+		Expect(GenerateMarkdownReference(&buf, rootCmd)).To(Succeed())
+		expectGolden("reference_md", buf.String())
+	})
+
+	It("should generate JSON reference", func() {
+		_, deployCmd := newTestCommandTree()
+
+		var labels map[string]string
+		Expect(AddFlag(deployCmd, &labels, "labels", map[string]string{"team": "core", "env": "dev", "app": "web"}, "Labels of the release", AddFlagOptions{Registry: newTestCommandTreeRegistry()})).To(Succeed())
+
+		Expect(GenerateJSONReference(&buf, deployCmd)).To(Succeed())
+		expectGolden("reference_json", buf.String())
+	})
+
+	It("should generate YAML reference", func() {
+		rootCmd, _ := newTestCommandTree()
+
+		Expect(GenerateYAMLReference(&buf, rootCmd)).To(Succeed())
+
+		ref := BuildCommandReference(rootCmd)
+		Expect(ref.CommandGroups).To(HaveLen(2))
+		Expect(ref.CommandGroups[0].Commands[0].Path).To(Equal("app plan"))
+		Expect(buf.String()).To(ContainSubstring("path: app release list"))
+	})
+})
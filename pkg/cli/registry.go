@@ -0,0 +1,141 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/werf/common-go/pkg/util"
+)
+
+// DefaultRegistry is used by AddFlag and other functions when no registry is specified. Its env
+// vars prefix is FlagEnvVarsPrefix.
+var DefaultRegistry = &Registry{
+	useGlobalPrefix:          true,
+	envProvider:              util.OSEnvProvider{},
+	definedFlagEnvVarRegexes: make(map[FlagRegexExpr]*regexp.Regexp),
+}
+
+// Registry holds the env vars prefix and the env var regexes of the flags added with AddFlag. Use
+// a separate registry for each CLI when building multiple CLIs in one process. Safe for
+// concurrent use.
+type Registry struct {
+	useGlobalPrefix bool
+	envVarsPrefix   string
+	envProvider     util.EnvProvider
+
+	mu                       sync.RWMutex
+	definedFlagEnvVarRegexes map[FlagRegexExpr]*regexp.Regexp
+	flagEnvVarConflicts      []FlagEnvVarConflict
+}
+
+type RegistryOptions struct {
+	// Source of env vars for the flag values. Defaults to the OS environment.
+	EnvProvider util.EnvProvider
+}
+
+func NewRegistry(envVarsPrefix string, opts RegistryOptions) *Registry {
+	if opts.EnvProvider == nil {
+		opts.EnvProvider = util.OSEnvProvider{}
+	}
+
+	return &Registry{
+		envVarsPrefix:            envVarsPrefix,
+		envProvider:              opts.EnvProvider,
+		definedFlagEnvVarRegexes: make(map[FlagRegexExpr]*regexp.Regexp),
+	}
+}
+
+// EnvVarsPrefix returns the prefix of env vars of the flags, e.g. "NELM_".
+func (r *Registry) EnvVarsPrefix() string {
+	if r.useGlobalPrefix {
+		return FlagEnvVarsPrefix
+	}
+
+	return r.envVarsPrefix
+}
+
+// EnvProvider returns the source of env vars for the flag values.
+func (r *Registry) EnvProvider() util.EnvProvider {
+	return r.envProvider
+}
+
+// DefinedFlagEnvVarRegexes returns a copy of the env var regexes of all the flags added so far.
+func (r *Registry) DefinedFlagEnvVarRegexes() map[FlagRegexExpr]*regexp.Regexp {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := make(map[FlagRegexExpr]*regexp.Regexp, len(r.definedFlagEnvVarRegexes))
+	for expr, regex := range r.definedFlagEnvVarRegexes {
+		result[expr] = regex
+	}
+
+	return result
+}
+
+func (r *Registry) defineFlagEnvVarRegex(expr FlagRegexExpr, regex *regexp.Regexp) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.definedFlagEnvVarRegexes[expr] = regex
+}
+
+// FlagEnvVarConflicts returns the scalar flags which matched multiple env vars, sorted by the
+// command path and the flag name.
+func (r *Registry) FlagEnvVarConflicts() []FlagEnvVarConflict {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := append([]FlagEnvVarConflict(nil), r.flagEnvVarConflicts...)
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].CommandPath != result[j].CommandPath {
+			return result[i].CommandPath < result[j].CommandPath
+		}
+		return result[i].FlagName < result[j].FlagName
+	})
+
+	return result
+}
+
+// WarnFlagEnvVarConflicts writes a warning for each of FlagEnvVarConflicts.
+func (r *Registry) WarnFlagEnvVarConflicts(w io.Writer) error {
+	for _, conflict := range r.FlagEnvVarConflicts() {
+		if _, err := fmt.Fprintf(w, "Warning: %s\n", conflict); err != nil {
+			return fmt.Errorf("write warning: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (r *Registry) addFlagEnvVarConflict(conflict FlagEnvVarConflict) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.flagEnvVarConflicts = append(r.flagEnvVarConflicts, conflict)
+}
+
+func registryOrDefault(registry *Registry) *Registry {
+	if registry == nil {
+		return DefaultRegistry
+	}
+
+	return registry
+}
+
+// FlagEnvVarConflict is a scalar flag, which matched multiple env vars. Only the env var with the
+// highest priority is used.
+type FlagEnvVarConflict struct {
+	CommandPath string
+	FlagName    string
+	UsedEnvVar  string
+	// From the lowest to the highest priority.
+	IgnoredEnvVars []string
+}
+
+func (c FlagEnvVarConflict) String() string {
+	return fmt.Sprintf("flag --%s of command %q is set from $%s, ignoring $%s", c.FlagName, c.CommandPath, c.UsedEnvVar, strings.Join(c.IgnoredEnvVars, ", $"))
+}
@@ -0,0 +1,85 @@
+package cli
+
+import (
+	"fmt"
+	"sync"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/werf/common-go/pkg/util"
+)
+
+var _ = Describe("Registry", func() {
+	It("should keep env vars of multiple CLIs separate when used concurrently", func() {
+		env := util.MapEnvProvider{
+			"FIRST_RUN_WORKERS":  "1",
+			"SECOND_RUN_WORKERS": "2",
+			"SECOND_RUN_UNKNOWN": "2",
+		}
+
+		registries := []*Registry{NewRegistry("FIRST_", RegistryOptions{EnvProvider: env}), NewRegistry("SECOND_", RegistryOptions{EnvProvider: env})}
+		workers := make([][]int, len(registries))
+
+		var wg sync.WaitGroup
+		for i, registry := range registries {
+			workers[i] = make([]int, 20)
+
+			for j := range workers[i] {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					defer GinkgoRecover()
+
+					cmd := newTestCommand()
+					Expect(AddFlag(cmd, &workers[i][j], "workers", 0, "", AddFlagOptions{Registry: registry})).To(Succeed())
+					Expect(AddFlag(cmd, new(string), fmt.Sprintf("flag-%d", j), "", "", AddFlagOptions{Registry: registry})).To(Succeed())
+				}()
+			}
+		}
+		wg.Wait()
+
+		for i := range registries {
+			for j := range workers[i] {
+				Expect(workers[i][j]).To(Equal(i + 1))
+			}
+		}
+
+		Expect(registries[0].EnvVarsPrefix()).To(Equal("FIRST_"))
+		Expect(registries[0].DefinedFlagEnvVarRegexes()).To(HaveLen(21))
+		Expect(registries[0].FindUndefinedFlagEnvVarsInEnviron()).To(BeEmpty())
+		Expect(registries[1].FindUndefinedFlagEnvVarsInEnviron()).To(ConsistOf("SECOND_RUN_UNKNOWN"))
+		Expect(DefaultRegistry.DefinedFlagEnvVarRegexes()).NotTo(HaveKey(FlagRegexExpr{Expr: "^FIRST_RUN_WORKERS$", Human: "$FIRST_RUN_WORKERS"}))
+	})
+
+	It("should use FlagEnvVarsPrefix for the default registry", func() {
+		DeferCleanup(func(prefix string) { FlagEnvVarsPrefix = prefix }, FlagEnvVarsPrefix)
+		FlagEnvVarsPrefix = "DEFAULT_"
+		Expect(DefaultRegistry.EnvVarsPrefix()).To(Equal("DEFAULT_"))
+
+		regexes, err := GetFlagGlobalEnvVarRegexes(newTestCommand(), "workers")
+		Expect(err).To(Succeed())
+		Expect(regexes).To(Equal([]*FlagRegexExpr{NewFlagRegexExpr("^DEFAULT_WORKERS$", "$DEFAULT_WORKERS")}))
+	})
+
+	It("should read env vars from the env provider", func() {
+		registry := NewRegistry("MAP_", RegistryOptions{EnvProvider: util.MapEnvProvider{
+			"MAP_RUN_WORKERS":  "3",
+			"MAP_RUN_LABELS_A": "team=core",
+			"MAP_RUN_UNKNOWN":  "1",
+		}})
+
+		cmd := newTestCommand()
+
+		var (
+			workers int
+			labels  map[string]string
+		)
+		Expect(AddFlag(cmd, &workers, "workers", 1, "", AddFlagOptions{Registry: registry})).To(Succeed())
+		Expect(AddFlag(cmd, &labels, "labels", nil, "", AddFlagOptions{Registry: registry})).To(Succeed())
+
+		Expect(workers).To(Equal(3))
+		Expect(labels).To(Equal(map[string]string{"team": "core"}))
+		Expect(registry.FindUndefinedFlagEnvVarsInEnviron()).To(ConsistOf("MAP_RUN_UNKNOWN"))
+	})
+})
@@ -0,0 +1,374 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"golang.org/x/term"
+)
+
+const (
+	defaultUsageWidth = 100
+	minUsageWidth     = 40
+
+	otherCommandsGroupTitle = "Other commands"
+	localFlagsGroupTitle    = "Options"
+	inheritedFlagsTitle     = "Global options"
+)
+
+type UsageOptions struct {
+	// Width to wrap the output to. If zero, the width of the terminal the command output (stdout
+	// for help, stderr for usage) is written to is used or 100 if the output is not a terminal.
+	Width int
+}
+
+// SetUsageAndHelp makes the command and all its subcommands render usage and help with RenderUsage.
+func SetUsageAndHelp(cmd *cobra.Command, opts UsageOptions) {
+	cmd.SetUsageFunc(func(c *cobra.Command) error {
+		_, err := io.WriteString(c.OutOrStderr(), RenderUsage(c, opts))
+		return err
+	})
+
+	cmd.SetHelpFunc(func(c *cobra.Command, _ []string) {
+		_, _ = io.WriteString(c.OutOrStdout(), RenderHelp(c, opts))
+	})
+}
+
+// RenderHelp renders the command description, examples (see SetCommandExamples, falls back to the
+// cobra Example field), the usage and related commands and links (see SetCommandSeeAlso).
+func RenderHelp(cmd *cobra.Command, opts UsageOptions) string {
+	width := usageWidth(opts, cmd.OutOrStdout())
+
+	description := cmd.Long
+	if description == "" {
+		description = cmd.Short
+	}
+
+	var b strings.Builder
+	if description = strings.TrimSpace(description); description != "" {
+		b.WriteString(wrapText(description, width))
+		b.WriteString("\n\n")
+	}
+
+	examples := cmd.Example
+	if structuredExamples := GetCommandExamples(cmd); len(structuredExamples) > 0 {
+		examples = formatCommandExamples(structuredExamples, width)
+	}
+
+	if examples = strings.TrimRight(examples, " \n"); examples != "" {
+		fmt.Fprintf(&b, "Examples:\n%s\n\n", examples)
+	}
+
+	b.WriteString(renderUsage(cmd, width))
+
+	if seeAlso := GetCommandSeeAlso(cmd); len(seeAlso) > 0 {
+		var rows [][2]string
+		for _, entry := range seeAlso {
+			row := [2]string{entry, ""}
+			if relatedCmd := findCommandByPath(cmd, entry); relatedCmd != nil {
+				row[1] = relatedCmd.Short
+			}
+
+			rows = append(rows, row)
+		}
+
+		b.WriteString("\nSee also:\n")
+		writeTwoColumns(&b, rows, width)
+	}
+
+	return b.String()
+}
+
+// RenderUsage renders the command usage: subcommands grouped by CommandGroup and flags grouped by
+// FlagGroup. Groups are sorted by priority (higher first), then by title. Commands are sorted by
+// priority (higher first), then by name. Local flags are followed by the inherited ones.
+func RenderUsage(cmd *cobra.Command, opts UsageOptions) string {
+	return renderUsage(cmd, usageWidth(opts, cmd.OutOrStderr()))
+}
+
+func renderUsage(cmd *cobra.Command, width int) string {
+	var b strings.Builder
+
+	b.WriteString("Usage:\n")
+	if cmd.Runnable() {
+		fmt.Fprintf(&b, "  %s\n", cmd.UseLine())
+	}
+	if cmd.HasAvailableSubCommands() {
+		fmt.Fprintf(&b, "  %s [command]\n", cmd.CommandPath())
+	}
+
+	for _, group := range buildCommandGroups(cmd) {
+		var rows [][2]string
+		for _, subCmd := range group.commands {
+			rows = append(rows, [2]string{subCmd.Name(), subCmd.Short})
+		}
+
+		fmt.Fprintf(&b, "\n%s:\n", group.title)
+		writeTwoColumns(&b, rows, width)
+	}
+
+	for _, group := range buildFlagGroups(cmd.LocalFlags(), localFlagsGroupTitle, false) {
+		fmt.Fprintf(&b, "\n%s:\n", group.title)
+		writeTwoColumns(&b, buildFlagRows(group.flags), width)
+	}
+
+	var inheritedFlags []*pflag.Flag
+	cmd.InheritedFlags().VisitAll(func(flag *pflag.Flag) {
+		if !flag.Hidden && flag.Deprecated == "" {
+			inheritedFlags = append(inheritedFlags, flag)
+		}
+	})
+
+	if len(inheritedFlags) > 0 {
+		fmt.Fprintf(&b, "\n%s:\n", inheritedFlagsTitle)
+		writeTwoColumns(&b, buildFlagRows(inheritedFlags), width)
+	}
+
+	if cmd.HasAvailableSubCommands() {
+		fmt.Fprintf(&b, "\nUse \"%s [command] --help\" for more information about a command.\n", cmd.CommandPath())
+	}
+
+	return b.String()
+}
+
+type commandGroup struct {
+	title    string
+	priority int
+	commands []*cobra.Command
+}
+
+// Group available subcommands by CommandGroup. Groups are sorted by priority (higher first), then
+// by title. Commands are sorted by priority (higher first), then by name.
+func buildCommandGroups(cmd *cobra.Command) []*commandGroup {
+	groups := map[string]*commandGroup{}
+
+	for _, subCmd := range cmd.Commands() {
+		if !subCmd.IsAvailableCommand() {
+			continue
+		}
+
+		groupID := subCmd.Annotations[CommandGroupIDAnnotationName]
+		if _, ok := groups[groupID]; !ok {
+			group := &commandGroup{title: otherCommandsGroupTitle, priority: minPriority}
+			if groupID != "" {
+				group.title = subCmd.Annotations[CommandGroupTitleAnnotationName]
+				group.priority = parsePriority(subCmd.Annotations[CommandGroupPriorityAnnotationName])
+			}
+			groups[groupID] = group
+		}
+
+		groups[groupID].commands = append(groups[groupID].commands, subCmd)
+	}
+
+	result := lo.Values(groups)
+	sortGroups(result, func(g *commandGroup) (int, string) { return g.priority, g.title })
+
+	for _, group := range result {
+		sort.SliceStable(group.commands, func(i, j int) bool {
+			iPriority := parsePriority(group.commands[i].Annotations[CommandPriorityAnnotationName])
+			jPriority := parsePriority(group.commands[j].Annotations[CommandPriorityAnnotationName])
+			if iPriority != jPriority {
+				return iPriority > jPriority
+			}
+			return group.commands[i].Name() < group.commands[j].Name()
+		})
+	}
+
+	return result
+}
+
+type flagGroup struct {
+	title    string
+	priority int
+	flags    []*pflag.Flag
+}
+
+// Group non-hidden flags by FlagGroup. Groups are sorted by priority (higher first), then by
+// title. Flags are sorted by name.
+func buildFlagGroups(flags *pflag.FlagSet, defaultTitle string, includeDeprecated bool) []*flagGroup {
+	groups := map[string]*flagGroup{}
+
+	flags.VisitAll(func(flag *pflag.Flag) {
+		// Deprecated flags are hidden too.
+		if flag.Deprecated != "" && !includeDeprecated || flag.Deprecated == "" && flag.Hidden {
+			return
+		}
+
+		groupID := firstAnnotation(flag, FlagGroupIDAnnotationName)
+		if _, ok := groups[groupID]; !ok {
+			group := &flagGroup{title: defaultTitle, priority: minPriority}
+			if groupID != "" {
+				group.title = firstAnnotation(flag, FlagGroupTitleAnnotationName)
+				group.priority = parsePriority(firstAnnotation(flag, FlagGroupPriorityAnnotationName))
+			}
+			groups[groupID] = group
+		}
+
+		groups[groupID].flags = append(groups[groupID].flags, flag)
+	})
+
+	result := lo.Values(groups)
+	sortGroups(result, func(g *flagGroup) (int, string) { return g.priority, g.title })
+
+	return result
+}
+
+func buildFlagRows(flags []*pflag.Flag) [][2]string {
+	var rows [][2]string
+	for _, flag := range flags {
+		rows = append(rows, buildFlagRow(flag))
+	}
+
+	return rows
+}
+
+func buildFlagRow(flag *pflag.Flag) [2]string {
+	varName, usage := pflag.UnquoteUsage(flag)
+
+	name := "    --" + flag.Name
+	if flag.Shorthand != "" && flag.ShorthandDeprecated == "" {
+		name = fmt.Sprintf("-%s, --%s", flag.Shorthand, flag.Name)
+	}
+	if varName != "" {
+		name += " " + varName
+	}
+
+	if !isZeroFlagDefault(flag) {
+		usage += " (default " + formatFlagDefault(flag) + ")"
+	}
+
+	return [2]string{name, usage}
+}
+
+func formatFlagDefault(flag *pflag.Flag) string {
+	if flag.Value.Type() == "string" {
+		return strconv.Quote(flag.DefValue)
+	}
+
+	return formatFlagDefValue(flag)
+}
+
+func isZeroFlagDefault(flag *pflag.Flag) bool {
+	switch flag.DefValue {
+	case "", "0", "0s", "0%", "false", "[]", "<nil>", "map[]":
+		return true
+	}
+
+	return false
+}
+
+func sortGroups[T any](groups []T, key func(group T) (int, string)) {
+	sort.SliceStable(groups, func(i, j int) bool {
+		iPriority, iTitle := key(groups[i])
+		jPriority, jTitle := key(groups[j])
+		if iPriority != jPriority {
+			return iPriority > jPriority
+		}
+		return iTitle < jTitle
+	})
+}
+
+const minPriority = -1 << 31
+
+func parsePriority(s string) int {
+	priority, err := strconv.Atoi(s)
+	if err != nil {
+		return 0
+	}
+
+	return priority
+}
+
+func firstAnnotation(flag *pflag.Flag, name string) string {
+	if values := flag.Annotations[name]; len(values) > 0 {
+		return values[0]
+	}
+
+	return ""
+}
+
+// Write rows as two columns, wrapping the second column. If the first column is too wide, the
+// second one starts on the next line.
+func writeTwoColumns(b *strings.Builder, rows [][2]string, width int) {
+	const indent = "  "
+	const gap = "  "
+
+	firstColumnWidth := 0
+	for _, row := range rows {
+		if len(row[0]) > firstColumnWidth && len(row[0]) <= width/3 {
+			firstColumnWidth = len(row[0])
+		}
+	}
+
+	secondColumnIndent := len(indent) + firstColumnWidth + len(gap)
+	secondColumnWidth := width - secondColumnIndent
+	padding := strings.Repeat(" ", secondColumnIndent)
+
+	for _, row := range rows {
+		lines := strings.Split(wrapText(row[1], secondColumnWidth), "\n")
+
+		if len(row[0]) > firstColumnWidth {
+			fmt.Fprintf(b, "%s%s\n", indent, row[0])
+		} else {
+			fmt.Fprintf(b, "%s%-*s%s%s\n", indent, firstColumnWidth, row[0], gap, lines[0])
+			lines = lines[1:]
+		}
+
+		for _, line := range lines {
+			if line == "" {
+				b.WriteString("\n")
+			} else {
+				fmt.Fprintf(b, "%s%s\n", padding, line)
+			}
+		}
+	}
+}
+
+// Wrap text by words, keeping existing line breaks. Words longer than the width are not split.
+func wrapText(text string, width int) string {
+	var result []string
+
+	for _, paragraph := range strings.Split(text, "\n") {
+		var line string
+		for _, word := range strings.Fields(paragraph) {
+			switch {
+			case line == "":
+				line = word
+			case len(line)+1+len(word) > width:
+				result = append(result, line)
+				line = word
+			default:
+				line += " " + word
+			}
+		}
+
+		result = append(result, line)
+	}
+
+	return strings.Join(result, "\n")
+}
+
+func usageWidth(opts UsageOptions, out io.Writer) int {
+	width := opts.Width
+	if width == 0 {
+		width = defaultUsageWidth
+		if file, ok := out.(*os.File); ok {
+			if termWidth, _, err := term.GetSize(int(file.Fd())); err == nil && termWidth > 0 {
+				width = termWidth
+			}
+		}
+	}
+
+	if width < minUsageWidth {
+		width = minUsageWidth
+	}
+
+	return width
+}
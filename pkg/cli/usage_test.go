@@ -0,0 +1,153 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/cobra"
+
+	"github.com/werf/common-go/pkg/util"
+)
+
+var updateGolden = flag.Bool("update-golden", false, "Update golden files in testdata")
+
+func expectGolden(name, actual string) {
+	path := filepath.Join("testdata", name+".golden")
+
+	if *updateGolden {
+		Expect(os.MkdirAll(filepath.Dir(path), 0o755)).To(Succeed())
+		Expect(os.WriteFile(path, []byte(actual), 0o644)).To(Succeed())
+	}
+
+	expected, err := os.ReadFile(path)
+	Expect(err).To(Succeed())
+	Expect(actual).To(Equal(string(expected)))
+}
+
+// Registry of the flags of the test command tree.
+func newTestCommandTreeRegistry() *Registry {
+	return NewRegistry("APP_", RegistryOptions{EnvProvider: util.MapEnvProvider{}})
+}
+
+func newTestCommandTree() (*cobra.Command, *cobra.Command) {
+	ctx := context.Background()
+	registry := newTestCommandTreeRegistry()
+
+	mainGroup := NewCommandGroup("main", "Main commands", 100)
+	managementGroup := NewCommandGroup("management", "Management commands", 50)
+
+	noop := func(cmd *cobra.Command, args []string) error { return nil }
+
+	rootCmd := NewRootCommand(ctx, "app", "App deploys applications to Kubernetes.")
+	rootCmd.PersistentFlags().String("kube-context", "", "Kubernetes context to use. Var: $APP_KUBE_CONTEXT")
+
+	deployCmd := NewSubCommand(ctx, "deploy [options]", "Deploy the release.", "Deploy the release to the cluster and wait until all resources are ready.", 10, mainGroup, SubCommandOptions{
+		Examples: []CommandExample{
+			{Description: "Deploy the chart from the current directory.", Command: "app deploy"},
+			{Description: "Deploy to the staging namespace with additional values, overriding the values from the chart.", Command: "app deploy -n staging \\\n  -f values-staging.yaml"},
+		},
+		SeeAlso: []string{"app plan", "https://example.com/docs/deploy"},
+	}, noop)
+	planCmd := NewSubCommand(ctx, "plan [options]", "Show what would change on deploy.", "", 20, mainGroup, SubCommandOptions{}, noop)
+	cleanupCmd := NewSubCommand(ctx, "cleanup", "Remove unused images from the container registry.", "", 0, managementGroup, SubCommandOptions{}, noop)
+	releaseCmd := NewGroupCommand(ctx, "release", "Manage releases.", "", managementGroup, GroupCommandOptions{})
+	releaseCmd.AddCommand(NewSubCommand(ctx, "list", "List releases.", "", 0, NewCommandGroup("release", "Release commands", 0), SubCommandOptions{}, noop))
+	hiddenCmd := NewSubCommand(ctx, "internal", "Internal command.", "", 0, mainGroup, SubCommandOptions{}, noop)
+	hiddenCmd.Hidden = true
+	rootCmd.AddCommand(deployCmd, planCmd, cleanupCmd, releaseCmd, hiddenCmd)
+
+	mainFlags := NewFlagGroup("main", "Main options", 100)
+	advancedFlags := NewFlagGroup("advanced", "Advanced options", 10)
+
+	var (
+		namespace  string
+		timeout    time.Duration
+		values     []string
+		autoRoll   bool
+		debug      bool
+		strategy   string
+		parallel   int
+		deprecated string
+	)
+	Expect(AddFlag(deployCmd, &namespace, "namespace", "default", "Namespace of the release", AddFlagOptions{Registry: registry, Group: mainFlags, ShortName: "n"})).To(Succeed())
+	Expect(AddFlag(deployCmd, &values, "values", nil, "Values files to use, which are merged in the order they are specified with the later ones taking precedence", AddFlagOptions{Registry: registry, Group: mainFlags, ShortName: "f", Examples: []string{"values.yaml"}})).To(Succeed())
+	Expect(AddFlag(deployCmd, &timeout, "timeout", 5*time.Minute, "Fail if not finished in time", AddFlagOptions{Registry: registry, Group: advancedFlags})).To(Succeed())
+	Expect(AddFlag(deployCmd, &autoRoll, "auto-rollback", false, "Rollback on failure", AddFlagOptions{Registry: registry, Group: advancedFlags})).To(Succeed())
+	Expect(AddFlag(deployCmd, &strategy, "strategy", "rolling", "Deploy strategy", AddFlagOptions{Registry: registry, Group: advancedFlags, AllowedValues: []string{"rolling", "recreate"}})).To(Succeed())
+	Expect(AddFlag(deployCmd, &parallel, "parallel", 0, "Max parallel operations", AddFlagOptions{Registry: registry, Hidden: true})).To(Succeed())
+	Expect(AddFlag(deployCmd, &deprecated, "old-flag", "", "Old flag", AddFlagOptions{Registry: registry, Deprecated: true})).To(Succeed())
+	Expect(AddFlag(deployCmd, &debug, "debug", false, "Enable debug output", AddFlagOptions{Registry: registry})).To(Succeed())
+
+	return rootCmd, deployCmd
+}
+
+var _ = Describe("RenderUsage", func() {
+	It("should render commands sorted by group and command priority", func() {
+		rootCmd, _ := newTestCommandTree()
+		expectGolden("usage_root", RenderUsage(rootCmd, UsageOptions{Width: 80}))
+	})
+
+	It("should render flags grouped and followed by inherited flags", func() {
+		_, deployCmd := newTestCommandTree()
+		expectGolden("usage_deploy", RenderUsage(deployCmd, UsageOptions{Width: 80}))
+	})
+
+	It("should use the default width if the output is not a terminal", func() {
+		_, deployCmd := newTestCommandTree()
+		deployCmd.SetOut(&bytes.Buffer{})
+		deployCmd.SetErr(&bytes.Buffer{})
+
+		Expect(RenderUsage(deployCmd, UsageOptions{})).To(Equal(RenderUsage(deployCmd, UsageOptions{Width: defaultUsageWidth})))
+		Expect(RenderHelp(deployCmd, UsageOptions{})).To(Equal(RenderHelp(deployCmd, UsageOptions{Width: defaultUsageWidth})))
+	})
+
+	It("should render map flag defaults with sorted keys", func() {
+		_, deployCmd := newTestCommandTree()
+
+		var labels map[string]string
+		Expect(AddFlag(deployCmd, &labels, "labels", map[string]string{"team": "core", "env": "dev", "app": "web"}, "Labels of the release", AddFlagOptions{Registry: newTestCommandTreeRegistry()})).To(Succeed())
+
+		Expect(RenderUsage(deployCmd, UsageOptions{Width: 200})).To(ContainSubstring("(default [app=web,env=dev,team=core])"))
+	})
+
+	It("should wrap to the narrow width", func() {
+		_, deployCmd := newTestCommandTree()
+		expectGolden("usage_deploy_narrow", RenderUsage(deployCmd, UsageOptions{Width: 50}))
+	})
+})
+
+var _ = Describe("SetCommandExamples", func() {
+	It("should set the cobra Example field", func() {
+		_, deployCmd := newTestCommandTree()
+
+		Expect(deployCmd.Example).To(Equal("" +
+			"  # Deploy the chart from the current directory.\n" +
+			"  app deploy\n" +
+			"\n" +
+			"  # Deploy to the staging namespace with additional values, overriding the values from the chart.\n" +
+			"  app deploy -n staging \\\n" +
+			"    -f values-staging.yaml",
+		))
+		Expect(GetCommandSeeAlso(deployCmd)).To(Equal([]string{"app plan", "https://example.com/docs/deploy"}))
+	})
+})
+
+var _ = Describe("SetUsageAndHelp", func() {
+	It("should render help for subcommands", func() {
+		rootCmd, _ := newTestCommandTree()
+		SetUsageAndHelp(rootCmd, UsageOptions{Width: 80})
+
+		var out bytes.Buffer
+		rootCmd.SetOut(&out)
+		rootCmd.SetArgs([]string{"deploy", "--help"})
+		Expect(rootCmd.Execute()).To(Succeed())
+
+		expectGolden("help_deploy", out.String())
+	})
+})
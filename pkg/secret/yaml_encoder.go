@@ -3,20 +3,24 @@ package secret
 import (
 	"bytes"
 	"fmt"
+	"sync"
+	"sync/atomic"
 
 	yaml_v3 "gopkg.in/yaml.v3"
 )
 
-// YamlEncoder is an Encoder compatible object with additional helpers to work with yaml data: EncryptYamlData and DecryptYamlData
+// YamlEncoder is an Encoder compatible object with additional helpers to work with yaml data: EncryptYamlData and DecryptYamlData.
+// Yaml values are processed sequentially unless SetMaxWorkers allows more workers.
 type YamlEncoder struct {
 	Encoder Encoder
 
 	generateFunc func([]byte) ([]byte, error)
 	extractFunc  func([]byte) ([]byte, error)
+	maxWorkers   int
 }
 
 func NewYamlEncoder(encoder Encoder) *YamlEncoder {
-	yamlEncoder := &YamlEncoder{Encoder: encoder}
+	yamlEncoder := &YamlEncoder{Encoder: encoder, maxWorkers: 1}
 
 	if encoder != nil {
 		yamlEncoder.generateFunc = encoder.Encrypt
@@ -29,6 +33,13 @@ func NewYamlEncoder(encoder Encoder) *YamlEncoder {
 	return yamlEncoder
 }
 
+// SetMaxWorkers sets the number of yaml values processed concurrently by EncryptYamlData and
+// DecryptYamlData, e.g. runtime.GOMAXPROCS(0). Defaults to 1, i.e. values are processed
+// sequentially. With more than 1 worker the Encoder must be safe for concurrent use.
+func (s *YamlEncoder) SetMaxWorkers(n int) {
+	s.maxWorkers = n
+}
+
 func (s *YamlEncoder) Encrypt(data []byte) ([]byte, error) {
 	resultData, err := s.generateFunc(data)
 	if err != nil {
@@ -39,7 +50,7 @@ func (s *YamlEncoder) Encrypt(data []byte) ([]byte, error) {
 }
 
 func (s *YamlEncoder) EncryptYamlData(data []byte) ([]byte, error) {
-	resultData, err := doYamlDataV2(s.generateFunc, data, encryptYamlMode)
+	resultData, err := doYamlDataV2(s.generateFunc, data, encryptYamlMode, s.maxWorkers)
 	if err != nil {
 		return nil, fmt.Errorf("encryption failed: check encryption key and data: %w", err)
 	}
@@ -61,7 +72,7 @@ func (s *YamlEncoder) Decrypt(data []byte) ([]byte, error) {
 }
 
 func (s *YamlEncoder) DecryptYamlData(data []byte) ([]byte, error) {
-	resultData, err := doYamlDataV2(s.extractFunc, data, decryptYamlMode)
+	resultData, err := doYamlDataV2(s.extractFunc, data, decryptYamlMode, s.maxWorkers)
 	if err != nil {
 		if IsExtractDataError(err) {
 			return nil, fmt.Errorf("decryption failed: check data `%s`: %w", string(data), err)
@@ -73,15 +84,17 @@ func (s *YamlEncoder) DecryptYamlData(data []byte) ([]byte, error) {
 	return resultData, nil
 }
 
-func doYamlDataV2(doFunc func([]byte) ([]byte, error), data []byte, mode yamlProcessorMode) ([]byte, error) {
+func doYamlDataV2(doFunc func([]byte) ([]byte, error), data []byte, mode yamlProcessorMode, workers int) ([]byte, error) {
 	var config yaml_v3.Node
 
 	if err := yaml_v3.Unmarshal(data, &config); err != nil {
 		return nil, fmt.Errorf("unable to unmarshal config data: %w", err)
 	}
 
-	resultConfig, err := doYamlValueSecretV2(doFunc, deepCopyNode(&config), mode)
-	if err != nil {
+	// Copy the whole tree once: alias targets become separate nodes, so every scalar is processed exactly once.
+	resultConfig := deepCopyNode(&config)
+
+	if err := processYamlScalarTasks(collectYamlScalarTasks(resultConfig, nil), doFunc, mode, workers); err != nil {
 		return nil, fmt.Errorf("unable to process config secrets: %w", err)
 	}
 
@@ -132,98 +145,165 @@ func deepCopyNode(node *yaml_v3.Node) *yaml_v3.Node {
 	return copyNode
 }
 
-func doYamlValueSecretV2(doFunc func([]byte) ([]byte, error), node *yaml_v3.Node, mode yamlProcessorMode) (*yaml_v3.Node, error) {
+// yamlScalarTask is a scalar node to be encrypted or decrypted in place. wrapErr adds the
+// path of the node to the error, the same way as the recursive traversal did before.
+type yamlScalarTask struct {
+	node    *yaml_v3.Node
+	wrapErr func(err error) error
+}
+
+func collectYamlScalarTasks(node *yaml_v3.Node, wrapErr func(err error) error) []yamlScalarTask {
+	if wrapErr == nil {
+		wrapErr = func(err error) error { return err }
+	}
+
+	var tasks []yamlScalarTask
+
 	switch node.Kind {
 	case yaml_v3.DocumentNode:
 		for pos := 0; pos < len(node.Content); pos += 1 {
-			newValueNode, err := doYamlValueSecretV2(doFunc, deepCopyNode(node.Content[pos]), mode)
-			if err != nil {
-				return nil, fmt.Errorf("unable to process document key %d: %w", pos, err)
-			}
-			node.Content[pos] = newValueNode
+			tasks = append(tasks, collectYamlScalarTasks(node.Content[pos], wrapYamlTaskErr(wrapErr, "unable to process document key %d", pos))...)
 		}
 
 	case yaml_v3.MappingNode:
 		for pos := 0; pos < len(node.Content); pos += 2 {
 			keyNode := node.Content[pos]
 			valueNode := node.Content[pos+1]
-			newValueNode, err := doYamlValueSecretV2(doFunc, deepCopyNode(valueNode), mode)
-			if err != nil {
-				return nil, fmt.Errorf("unable to process map key %q value=%v: %w", keyNode.Value, valueNode.Value, err)
-			}
-			node.Content[pos+1] = newValueNode
+			tasks = append(tasks, collectYamlScalarTasks(valueNode, wrapYamlTaskErr(wrapErr, "unable to process map key %q value=%v", keyNode.Value, valueNode.Value))...)
 		}
 
 	case yaml_v3.SequenceNode:
 		for pos := 0; pos < len(node.Content); pos += 1 {
-			newValueNode, err := doYamlValueSecretV2(doFunc, deepCopyNode(node.Content[pos]), mode)
-			if err != nil {
-				return nil, fmt.Errorf("unable to process array key %d: %w", pos, err)
-			}
-			node.Content[pos] = newValueNode
+			tasks = append(tasks, collectYamlScalarTasks(node.Content[pos], wrapYamlTaskErr(wrapErr, "unable to process array key %d", pos))...)
 		}
 
 	case yaml_v3.AliasNode:
-		newAliasNode, err := doYamlValueSecretV2(doFunc, deepCopyNode(node.Alias), mode)
-		if err != nil {
-			return nil, fmt.Errorf("unable to process an alias node %q: %w", node.Value, err)
+		if node.Alias != nil {
+			tasks = append(tasks, collectYamlScalarTasks(node.Alias, wrapYamlTaskErr(wrapErr, "unable to process an alias node %q", node.Value))...)
 		}
-		node.Alias = newAliasNode
 
 	case yaml_v3.ScalarNode:
-		switch mode {
-		case decryptYamlMode:
-			switch node.ShortTag() {
-			case "!!null":
-			// ignore
+		tasks = append(tasks, yamlScalarTask{node: node, wrapErr: wrapErr})
+	}
 
-			case "!!str":
-				var value string
+	return tasks
+}
 
-				if err := node.Decode(&value); err != nil {
-					return nil, fmt.Errorf("unable to decode string value %q: %w", node.Value, err)
-				}
+func wrapYamlTaskErr(parentWrapErr func(err error) error, format string, args ...interface{}) func(err error) error {
+	return func(err error) error {
+		return parentWrapErr(fmt.Errorf(format+": %w", append(args, err)...))
+	}
+}
+
+// processYamlScalarTasks runs tasks in a pool of at most workers goroutines. If several tasks
+// fail, the error of the first one in document order is returned.
+func processYamlScalarTasks(tasks []yamlScalarTask, doFunc func([]byte) ([]byte, error), mode yamlProcessorMode, workers int) error {
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > len(tasks) {
+		workers = len(tasks)
+	}
 
-				newValue, err := doFunc([]byte(value))
-				if err != nil {
-					return nil, err
+	errs := make([]error, len(tasks))
+	var firstFailedInd atomic.Int64
+	firstFailedInd.Store(int64(len(tasks)))
+
+	tasksCh := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for ind := range tasksCh {
+				// Tasks after an already failed one will not affect the result.
+				if int64(ind) > firstFailedInd.Load() {
+					continue
 				}
 
-				if err := node.Encode(string(newValue)); err != nil {
-					return nil, fmt.Errorf("unable to encode string value %q: %w", string(newValue), err)
+				if err := doYamlScalarSecretV2(doFunc, tasks[ind].node, mode); err != nil {
+					errs[ind] = tasks[ind].wrapErr(err)
+
+					for {
+						failedInd := firstFailedInd.Load()
+						if int64(ind) >= failedInd || firstFailedInd.CompareAndSwap(failedInd, int64(ind)) {
+							break
+						}
+					}
 				}
-			default:
-				return nil, fmt.Errorf("unable to decode non string value %q: expected encoded value as hex string", node.Value)
 			}
+		}()
+	}
 
-		case encryptYamlMode:
-			// FIXME: support all types, by node.ShortTag()
+	for ind := range tasks {
+		tasksCh <- ind
+	}
+	close(tasksCh)
+	wg.Wait()
 
-			switch node.ShortTag() {
-			case "!!null":
-			// ignore
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
 
-			default:
-				var value interface{}
+	return nil
+}
 
-				if err := node.Decode(&value); err != nil {
-					return nil, fmt.Errorf("unable to decode string value %q: %w", node.Value, err)
-				}
+func doYamlScalarSecretV2(doFunc func([]byte) ([]byte, error), node *yaml_v3.Node, mode yamlProcessorMode) error {
+	switch mode {
+	case decryptYamlMode:
+		switch node.ShortTag() {
+		case "!!null":
+		// ignore
 
-				// FIXME: this is compatibility mode with previous werf version
-				newValue, err := doFunc([]byte(fmt.Sprintf("%v", value)))
-				if err != nil {
-					return nil, err
-				}
+		case "!!str":
+			var value string
 
-				if err := node.Encode(string(newValue)); err != nil {
-					return nil, fmt.Errorf("unable to encode string value %q: %w", string(newValue), err)
-				}
+			if err := node.Decode(&value); err != nil {
+				return fmt.Errorf("unable to decode string value %q: %w", node.Value, err)
+			}
+
+			newValue, err := doFunc([]byte(value))
+			if err != nil {
+				return err
+			}
+
+			if err := node.Encode(string(newValue)); err != nil {
+				return fmt.Errorf("unable to encode string value %q: %w", string(newValue), err)
+			}
+		default:
+			return fmt.Errorf("unable to decode non string value %q: expected encoded value as hex string", node.Value)
+		}
+
+	case encryptYamlMode:
+		// FIXME: support all types, by node.ShortTag()
+
+		switch node.ShortTag() {
+		case "!!null":
+		// ignore
+
+		default:
+			var value interface{}
+
+			if err := node.Decode(&value); err != nil {
+				return fmt.Errorf("unable to decode string value %q: %w", node.Value, err)
+			}
+
+			// FIXME: this is compatibility mode with previous werf version
+			newValue, err := doFunc([]byte(fmt.Sprintf("%v", value)))
+			if err != nil {
+				return err
+			}
+
+			if err := node.Encode(string(newValue)); err != nil {
+				return fmt.Errorf("unable to encode string value %q: %w", string(newValue), err)
 			}
 		}
 	}
 
-	return node, nil
+	return nil
 }
 
 func doNothing(data []byte) ([]byte, error) { return data, nil }
@@ -0,0 +1,127 @@
+package secret
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	yaml_v3 "gopkg.in/yaml.v3"
+)
+
+// Run with: go test -run '^$' -bench YamlData ./pkg/secret/
+
+func BenchmarkEncryptYamlData(b *testing.B) {
+	benchmarkYamlData(b, encryptYamlMode)
+}
+
+func BenchmarkDecryptYamlData(b *testing.B) {
+	benchmarkYamlData(b, decryptYamlMode)
+}
+
+func benchmarkYamlData(b *testing.B, mode yamlProcessorMode) {
+	aesEncoder, err := NewAesEncoder(AesSecretKey)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	yamlEncoder := NewYamlEncoder(aesEncoder)
+
+	for _, doc := range []struct {
+		name         string
+		width, depth int
+	}{
+		{name: "wide", width: 2000, depth: 1},
+		{name: "deep", width: 4, depth: 500},
+	} {
+		data := generateYamlSecretsDocument(doc.width, doc.depth)
+
+		doFunc := yamlEncoder.generateFunc
+		if mode == decryptYamlMode {
+			data, err = yamlEncoder.EncryptYamlData(data)
+			if err != nil {
+				b.Fatal(err)
+			}
+
+			doFunc = yamlEncoder.extractFunc
+		}
+
+		b.Run(fmt.Sprintf("%s/legacy", doc.name), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := legacyDoYamlDataV2(doFunc, data, mode); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+
+		for _, workers := range []int{1, 4, 16} {
+			b.Run(fmt.Sprintf("%s/workers=%d", doc.name, workers), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					if _, err := doYamlDataV2(doFunc, data, mode, workers); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
+
+// legacyDoYamlDataV2 is the previous sequential implementation, which deep-copies every subtree
+// before recursing into it. Kept only to compare against in benchmarks.
+func legacyDoYamlDataV2(doFunc func([]byte) ([]byte, error), data []byte, mode yamlProcessorMode) ([]byte, error) {
+	var config yaml_v3.Node
+
+	if err := yaml_v3.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("unable to unmarshal config data: %w", err)
+	}
+
+	resultConfig, err := legacyDoYamlValueSecretV2(doFunc, deepCopyNode(&config), mode)
+	if err != nil {
+		return nil, fmt.Errorf("unable to process config secrets: %w", err)
+	}
+
+	var resultData bytes.Buffer
+
+	yamlEncoder := yaml_v3.NewEncoder(&resultData)
+	yamlEncoder.SetIndent(2)
+	if err := yamlEncoder.Encode(resultConfig); err != nil {
+		return nil, fmt.Errorf("unable to marshal modified config data: %w", err)
+	}
+
+	return resultData.Bytes(), nil
+}
+
+func legacyDoYamlValueSecretV2(doFunc func([]byte) ([]byte, error), node *yaml_v3.Node, mode yamlProcessorMode) (*yaml_v3.Node, error) {
+	switch node.Kind {
+	case yaml_v3.DocumentNode, yaml_v3.SequenceNode:
+		for pos := 0; pos < len(node.Content); pos += 1 {
+			newValueNode, err := legacyDoYamlValueSecretV2(doFunc, deepCopyNode(node.Content[pos]), mode)
+			if err != nil {
+				return nil, err
+			}
+			node.Content[pos] = newValueNode
+		}
+
+	case yaml_v3.MappingNode:
+		for pos := 0; pos < len(node.Content); pos += 2 {
+			newValueNode, err := legacyDoYamlValueSecretV2(doFunc, deepCopyNode(node.Content[pos+1]), mode)
+			if err != nil {
+				return nil, err
+			}
+			node.Content[pos+1] = newValueNode
+		}
+
+	case yaml_v3.AliasNode:
+		newAliasNode, err := legacyDoYamlValueSecretV2(doFunc, deepCopyNode(node.Alias), mode)
+		if err != nil {
+			return nil, err
+		}
+		node.Alias = newAliasNode
+
+	case yaml_v3.ScalarNode:
+		if err := doYamlScalarSecretV2(doFunc, node, mode); err != nil {
+			return nil, err
+		}
+	}
+
+	return node, nil
+}
@@ -1,9 +1,12 @@
 package secret
 
 import (
+	"bytes"
 	"fmt"
 	"gopkg.in/yaml.v3"
 	"strings"
+	"sync/atomic"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -176,8 +179,98 @@ mybinary: |
 	})
 })
 
+var _ = Describe("YamlEncoder concurrent processing", func() {
+	It("should process values sequentially by default", func() {
+		encoder := &concurrencyTrackingEncoderMock{}
+
+		_, err := NewYamlEncoder(encoder).EncryptYamlData(generateYamlSecretsDocument(20, 3))
+		Expect(err).To(Succeed())
+		Expect(encoder.maxActive.Load()).To(Equal(int32(1)))
+	})
+
+	It("should produce the same result regardless of the number of workers", func() {
+		originalData := generateYamlSecretsDocument(20, 10)
+
+		sequentialEnc := NewYamlEncoder(&EncoderMock{})
+		sequentialEnc.SetMaxWorkers(1)
+		parallelEnc := NewYamlEncoder(&EncoderMock{})
+		parallelEnc.SetMaxWorkers(8)
+
+		sequentialData, err := sequentialEnc.EncryptYamlData(originalData)
+		Expect(err).To(Succeed())
+		parallelData, err := parallelEnc.EncryptYamlData(originalData)
+		Expect(err).To(Succeed())
+		Expect(string(parallelData)).To(Equal(string(sequentialData)))
+
+		resultData, err := parallelEnc.DecryptYamlData(parallelData)
+		Expect(err).To(Succeed())
+		Expect(string(resultData)).To(Equal(string(originalData)))
+	})
+
+	It("should report the first failed value in document order", func() {
+		enc := NewYamlEncoder(&EncoderMock{})
+		enc.SetMaxWorkers(4)
+
+		_, err := enc.DecryptYamlData([]byte(`
+a: 'encoded: one'
+b:
+  c: 1
+d: 2
+`))
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring(`unable to process map key "b" value=: unable to process map key "c" value=1: unable to decode non string value "1"`))
+	})
+})
+
+// generateYamlSecretsDocument generates a yaml document of depth nested maps with width string values on each level.
+func generateYamlSecretsDocument(width, depth int) []byte {
+	var generate func(level int) map[string]interface{}
+	generate = func(level int) map[string]interface{} {
+		result := map[string]interface{}{}
+		for i := 0; i < width; i++ {
+			result[fmt.Sprintf("key%d", i)] = fmt.Sprintf("value-%d-%d", level, i)
+		}
+		if level < depth {
+			result["nested"] = generate(level + 1)
+		}
+		return result
+	}
+
+	var buf bytes.Buffer
+	yamlEncoder := yaml.NewEncoder(&buf)
+	yamlEncoder.SetIndent(2)
+	if err := yamlEncoder.Encode(generate(1)); err != nil {
+		panic(err)
+	}
+
+	return buf.Bytes()
+}
+
 type EncoderMock struct{}
 
+// concurrencyTrackingEncoderMock records the max number of concurrent Encrypt calls.
+type concurrencyTrackingEncoderMock struct {
+	EncoderMock
+
+	active    atomic.Int32
+	maxActive atomic.Int32
+}
+
+func (s *concurrencyTrackingEncoderMock) Encrypt(data []byte) ([]byte, error) {
+	active := s.active.Add(1)
+	defer s.active.Add(-1)
+
+	for {
+		maxActive := s.maxActive.Load()
+		if active <= maxActive || s.maxActive.CompareAndSwap(maxActive, active) {
+			break
+		}
+	}
+	time.Sleep(time.Millisecond)
+
+	return s.EncoderMock.Encrypt(data)
+}
+
 func (s *EncoderMock) Encrypt(data []byte) ([]byte, error) {
 	return []byte(fmt.Sprintf("encoded: %s", data)), nil
 }
@@ -0,0 +1,157 @@
+package secretvalues
+
+// ahoCorasickMatcher finds occurrences of many patterns in a single pass over the data.
+type ahoCorasickMatcher struct {
+	patterns []string
+	nodes    []ahoCorasickNode
+}
+
+type ahoCorasickNode struct {
+	next  map[byte]int
+	fail  int
+	depth int
+	// Index of the pattern ending in this node or -1.
+	pattern int
+	// Closest node on the fail chain that has a pattern or -1.
+	outputLink int
+}
+
+type ahoCorasickMatch struct {
+	Start   int
+	End     int
+	Pattern int
+}
+
+func newAhoCorasickMatcher(patterns []string) *ahoCorasickMatcher {
+	m := &ahoCorasickMatcher{
+		patterns: patterns,
+		nodes:    []ahoCorasickNode{newAhoCorasickNode(0)},
+	}
+
+	for patternInd, pattern := range patterns {
+		if pattern == "" {
+			continue
+		}
+
+		cur := 0
+		for i := 0; i < len(pattern); i++ {
+			next, ok := m.nodes[cur].next[pattern[i]]
+			if !ok {
+				next = len(m.nodes)
+				m.nodes = append(m.nodes, newAhoCorasickNode(m.nodes[cur].depth+1))
+				m.nodes[cur].next[pattern[i]] = next
+			}
+			cur = next
+		}
+
+		if m.nodes[cur].pattern == -1 {
+			m.nodes[cur].pattern = patternInd
+		}
+	}
+
+	queue := []int{}
+	for _, child := range m.nodes[0].next {
+		queue = append(queue, child)
+	}
+
+	for len(queue) > 0 {
+		var cur int
+		cur, queue = queue[0], queue[1:]
+
+		for b, child := range m.nodes[cur].next {
+			fail := m.nodes[cur].fail
+			for {
+				if next, ok := m.nodes[fail].next[b]; ok && next != child {
+					fail = next
+					break
+				}
+				if fail == 0 {
+					break
+				}
+				fail = m.nodes[fail].fail
+			}
+
+			m.nodes[child].fail = fail
+			if m.nodes[fail].pattern != -1 {
+				m.nodes[child].outputLink = fail
+			} else {
+				m.nodes[child].outputLink = m.nodes[fail].outputLink
+			}
+
+			queue = append(queue, child)
+		}
+	}
+
+	return m
+}
+
+func newAhoCorasickNode(depth int) ahoCorasickNode {
+	return ahoCorasickNode{
+		next:       map[byte]int{},
+		depth:      depth,
+		pattern:    -1,
+		outputLink: -1,
+	}
+}
+
+func (m *ahoCorasickMatcher) step(state int, b byte) int {
+	for {
+		if next, ok := m.nodes[state].next[b]; ok {
+			return next
+		}
+		if state == 0 {
+			return 0
+		}
+		state = m.nodes[state].fail
+	}
+}
+
+// Find returns leftmost-longest non-overlapping matches. Only matches which can't change when
+// more data is appended are returned, so the data starting from the returned offset must be
+// rescanned together with the following data. If final is true, the data is considered
+// complete and the offset is always len(data).
+func (m *ahoCorasickMatcher) Find(data []byte, final bool) ([]ahoCorasickMatch, int) {
+	// Longest pattern starting at each position, -1 if none.
+	longestAt := make([]int, len(data))
+	for i := range longestAt {
+		longestAt[i] = -1
+	}
+
+	state := 0
+	for i := 0; i < len(data); i++ {
+		state = m.step(state, data[i])
+
+		for node := state; node != -1; node = m.nodes[node].outputLink {
+			if m.nodes[node].pattern == -1 {
+				continue
+			}
+
+			start := i + 1 - m.nodes[node].depth
+			if longestAt[start] == -1 || len(m.patterns[longestAt[start]]) < m.nodes[node].depth {
+				longestAt[start] = m.nodes[node].pattern
+			}
+		}
+	}
+
+	// The longest suffix of the data which is a prefix of some pattern is undecided: it might
+	// become a match when more data arrives.
+	undecidedFrom := len(data)
+	if !final {
+		undecidedFrom = len(data) - m.nodes[state].depth
+	}
+
+	var matches []ahoCorasickMatch
+	offset := 0
+	for offset < undecidedFrom {
+		if longestAt[offset] == -1 {
+			offset++
+			continue
+		}
+
+		end := offset + len(m.patterns[longestAt[offset]])
+		matches = append(matches, ahoCorasickMatch{Start: offset, End: end, Pattern: longestAt[offset]})
+		offset = end
+	}
+
+	return matches, offset
+}
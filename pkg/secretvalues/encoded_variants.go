@@ -0,0 +1,83 @@
+package secretvalues
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+type encodedVariant struct {
+	Encoding string
+	Value    string
+}
+
+// EncodedVariants returns the forms a secret value usually takes when rendered into manifests or
+// logs: base64 (e.g. data of Kubernetes Secrets), URL-encoded, JSON-escaped, Go-quoted and YAML
+// single-quoted. Variants equal to the value itself or to each other are omitted.
+func EncodedVariants(value string) []string {
+	var result []string
+	for _, variant := range encodedVariants(value) {
+		result = append(result, variant.Value)
+	}
+
+	return result
+}
+
+func encodedVariants(value string) []encodedVariant {
+	var variants []encodedVariant
+	seen := map[string]struct{}{value: {}}
+
+	add := func(encoding, variant string) {
+		if _, ok := seen[variant]; ok {
+			return
+		}
+
+		seen[variant] = struct{}{}
+		variants = append(variants, encodedVariant{Encoding: encoding, Value: variant})
+	}
+
+	add("base64", base64.StdEncoding.EncodeToString([]byte(value)))
+	add("base64url", base64.URLEncoding.EncodeToString([]byte(value)))
+	add("urlquery", url.QueryEscape(value))
+	add("urlpath", url.PathEscape(value))
+
+	if jsonQuoted, err := json.Marshal(value); err == nil {
+		add("json", strings.TrimSuffix(strings.TrimPrefix(string(jsonQuoted), `"`), `"`))
+	}
+
+	goQuoted := strconv.Quote(value)
+	add("goquoted", goQuoted[1:len(goQuoted)-1])
+
+	add("yamlquoted", strings.ReplaceAll(value, "'", "''"))
+
+	return variants
+}
+
+func appendEncodedVariants(secretValues []SecretValue) []SecretValue {
+	seen := make(map[string]struct{}, len(secretValues))
+	for _, secretValue := range secretValues {
+		seen[secretValue.Value] = struct{}{}
+	}
+
+	result := secretValues
+	for _, secretValue := range secretValues {
+		for _, variant := range encodedVariants(secretValue.Value) {
+			if _, ok := seen[variant.Value]; ok {
+				continue
+			}
+
+			seen[variant.Value] = struct{}{}
+
+			variantSecretValue := secretValue
+			variantSecretValue.Value = variant.Value
+			variantSecretValue.Origin = SecretValueOriginEncodedVariant
+			variantSecretValue.Encoding = variant.Encoding
+			variantSecretValue.ID = secretValue.ID + "#" + variant.Encoding
+			result = append(result, variantSecretValue)
+		}
+	}
+
+	return result
+}
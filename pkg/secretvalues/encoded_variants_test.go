@@ -0,0 +1,34 @@
+package secretvalues
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("EncodedVariants", func() {
+	DescribeTable("should return distinct encoded variants of the secret value",
+		func(value string, expected []string) {
+			Expect(EncodedVariants(value)).To(Equal(expected))
+		},
+		Entry("plain value", "qwerty", []string{"cXdlcnR5"}),
+		Entry("value with special characters", "p@ss w/\"q'?\n", []string{
+			"cEBzcyB3LyJxJz8K",
+			"p%40ss+w%2F%22q%27%3F%0A",
+			"p@ss%20w%2F%22q%27%3F%0A",
+			`p@ss w/\"q'?\n`,
+			"p@ss w/\"q''?\n",
+		}),
+		Entry("value with url-unsafe base64", "??>", []string{"Pz8+", "Pz8-", "%3F%3F%3E", `??\u003e`}),
+	)
+})
+
+var _ = Describe("ExtractSecretValuesFromMapWithOptions", func() {
+	It("should add encoded variants of secret values", func() {
+		values, err := ExtractSecretValuesFromMapWithOptions(map[string]interface{}{
+			"password": "p@ssword",
+		}, ExtractSecretValuesOptions{IncludeEncodedVariants: true})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(values).To(Equal([]string{"p@ssword", "cEBzc3dvcmQ=", "p%40ssword"}))
+	})
+})
@@ -0,0 +1,30 @@
+package secretvalues
+
+import (
+	"fmt"
+
+	yaml_v3 "gopkg.in/yaml.v3"
+
+	"github.com/werf/common-go/pkg/secret"
+)
+
+// ExtractSecretValuesFromEncryptedYaml decrypts YAML data encrypted with secret.YamlEncoder and
+// returns the decrypted data along with the secret values to mask.
+func ExtractSecretValuesFromEncryptedYaml(encryptedData []byte, encoder secret.Encoder, opts ExtractSecretValuesOptions) ([]byte, []SecretValue, error) {
+	decryptedData, err := secret.NewYamlEncoder(encoder).DecryptYamlData(encryptedData)
+	if err != nil {
+		return nil, nil, fmt.Errorf("decrypt yaml data: %w", err)
+	}
+
+	var data map[string]interface{}
+	if err := yaml_v3.Unmarshal(decryptedData, &data); err != nil {
+		return nil, nil, fmt.Errorf("unmarshal decrypted yaml data: %w", err)
+	}
+
+	secretValues, err := ExtractSecretValuesWithKeyPaths(data, opts)
+	if err != nil {
+		return nil, nil, fmt.Errorf("extract secret values: %w", err)
+	}
+
+	return decryptedData, secretValues, nil
+}
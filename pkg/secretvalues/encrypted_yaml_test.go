@@ -0,0 +1,66 @@
+package secretvalues
+
+import (
+	"encoding/base64"
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/werf/common-go/pkg/secret"
+)
+
+var _ = Describe("ExtractSecretValuesFromEncryptedYaml", func() {
+	It("should return decrypted data and secret values with key paths", func() {
+		encoder, err := secret.NewAesEncoder([]byte("11ac8312520b5ff037bae386ea2e8a07"))
+		Expect(err).To(Succeed())
+
+		encryptedData, err := secret.NewYamlEncoder(encoder).EncryptYamlData([]byte(fmt.Sprintf(`
+app:
+  db:
+    password: qwerty
+  config: |
+    server:
+      token: s3cr3t-t0k3n
+  encoded: %s
+`, base64.StdEncoding.EncodeToString([]byte("base64-secret")))))
+		Expect(err).To(Succeed())
+
+		decryptedData, secretValues, err := ExtractSecretValuesFromEncryptedYaml(encryptedData, encoder, ExtractSecretValuesOptions{
+			NestedFormatParsers: AllNestedFormatParsers(),
+		})
+		Expect(err).To(Succeed())
+
+		Expect(string(decryptedData)).To(ContainSubstring("password: qwerty"))
+		Expect(secretValues).To(ContainElements(
+			SecretValue{Value: "qwerty", KeyPath: "app.db.password", Origin: SecretValueOriginValue, ID: "app.db.password"},
+			SecretValue{Value: "s3cr3t-t0k3n", KeyPath: "app.config.server.token", Origin: SecretValueOriginValue, NestedFormat: "yaml", ID: "app.config.server.token#yaml"},
+			SecretValue{Value: "base64-secret", KeyPath: "app.encoded", Origin: SecretValueOriginValue, NestedFormat: "base64", ID: "app.encoded#base64"},
+		))
+	})
+
+	It("should parse nested YAML and base64 documents with default options", func() {
+		encoder, err := secret.NewAesEncoder([]byte("11ac8312520b5ff037bae386ea2e8a07"))
+		Expect(err).To(Succeed())
+
+		encryptedData, err := secret.NewYamlEncoder(encoder).EncryptYamlData([]byte(fmt.Sprintf("app:\n  bundle: %s\n", base64.StdEncoding.EncodeToString([]byte("server:\n  token: n3st3d-t0k3n\n")))))
+		Expect(err).To(Succeed())
+
+		_, secretValues, err := ExtractSecretValuesFromEncryptedYaml(encryptedData, encoder, ExtractSecretValuesOptions{})
+		Expect(err).To(Succeed())
+
+		Expect(secretValues).To(ContainElement(SatisfyAll(
+			HaveField("Value", "n3st3d-t0k3n"),
+			HaveField("KeyPath", "app.bundle.server.token"),
+			HaveField("NestedFormat", "yaml"),
+		)))
+	})
+
+	It("should fail if the data can't be decrypted", func() {
+		encoder, err := secret.NewAesEncoder([]byte("11ac8312520b5ff037bae386ea2e8a07"))
+		Expect(err).To(Succeed())
+
+		_, _, err = ExtractSecretValuesFromEncryptedYaml([]byte("password: qwerty\n"), encoder, ExtractSecretValuesOptions{})
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -1,63 +1,155 @@
 package secretvalues
 
 import (
-	"encoding/json"
 	"fmt"
 	"reflect"
+	"sort"
 	"strings"
 )
 
+type ExtractSecretValuesOptions struct {
+	// Decides which values are masked. Defaults to DefaultMaskPolicy().
+	Policy *MaskPolicy
+
+	// Also return encoded variants of each secret value (see EncodedVariants), so that secret
+	// values are masked even after being base64-encoded, URL-encoded, escaped or quoted.
+	IncludeEncodedVariants bool
+
+	// Parsers of documents stored in string values, tried in order until one succeeds.
+	// Defaults to DefaultNestedFormatParsers().
+	NestedFormatParsers []NestedFormatParser
+	// Documents nested deeper than this are not parsed. Defaults to DefaultMaxNestingDepth.
+	MaxNestingDepth int
+
+	// Return every occurrence of each secret value, e.g. to audit all key paths a value is found
+	// by. By default only the first occurrence is returned, see DeduplicateSecretValues.
+	KeepDuplicates bool
+}
+
 func ExtractSecretValuesFromMap(data map[string]interface{}) []string {
-	queue := []interface{}{data}
-	maskedValues := []string{}
+	// Never fails with the default options.
+	maskedValues, _ := ExtractSecretValuesFromMapWithOptions(data, ExtractSecretValuesOptions{})
+
+	return maskedValues
+}
+
+// ExtractSecretValuesFromMapWithOptions returns an error if opts.Policy is not valid, see
+// MaskPolicy.Validate.
+func ExtractSecretValuesFromMapWithOptions(data map[string]interface{}, opts ExtractSecretValuesOptions) ([]string, error) {
+	secretValues, err := ExtractSecretValuesWithKeyPaths(data, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	maskedValues := make([]string, 0, len(secretValues))
+	for _, secretValue := range secretValues {
+		maskedValues = append(maskedValues, secretValue.Value)
+	}
+
+	return maskedValues, nil
+}
+
+// ExtractSecretValuesWithKeyPaths is like ExtractSecretValuesFromMapWithOptions, but also
+// returns where each secret value was found.
+func ExtractSecretValuesWithKeyPaths(data map[string]interface{}, opts ExtractSecretValuesOptions) ([]SecretValue, error) {
+	if opts.Policy == nil {
+		opts.Policy = DefaultMaskPolicy()
+	}
+
+	// Otherwise malformed key path patterns silently never match and secret values are not masked.
+	if err := opts.Policy.Validate(); err != nil {
+		return nil, fmt.Errorf("validate mask policy: %w", err)
+	}
+
+	if opts.NestedFormatParsers == nil {
+		opts.NestedFormatParsers = DefaultNestedFormatParsers()
+	}
+
+	if opts.MaxNestingDepth == 0 {
+		opts.MaxNestingDepth = DefaultMaxNestingDepth
+	}
+
+	type queueElem struct {
+		value        interface{}
+		keyPath      string
+		nestedFormat string
+		nestingDepth int
+	}
+
+	queue := []queueElem{{value: data}}
+	secretValues := []SecretValue{}
 
 	for len(queue) > 0 {
-		var elemI interface{}
-		elemI, queue = queue[0], queue[1:]
+		var elem queueElem
+		elem, queue = queue[0], queue[1:]
 
-		elemType := reflect.TypeOf(elemI)
+		elemType := reflect.TypeOf(elem.value)
 		if elemType == nil {
 			continue
 		}
 
 		switch elemType.Kind() {
 		case reflect.Slice, reflect.Array:
-			elem := reflect.ValueOf(elemI)
-			for i := 0; i < elem.Len(); i++ {
-				value := elem.Index(i)
-				queue = append(queue, value.Interface())
+			value := reflect.ValueOf(elem.value)
+			for i := 0; i < value.Len(); i++ {
+				queue = append(queue, queueElem{value: value.Index(i).Interface(), keyPath: JoinKeyPathIndex(elem.keyPath, i), nestedFormat: elem.nestedFormat, nestingDepth: elem.nestingDepth})
 			}
 		case reflect.Map:
-			elem := reflect.ValueOf(elemI)
-			for _, key := range elem.MapKeys() {
-				value := elem.MapIndex(key)
-				queue = append(queue, value.Interface())
+			value := reflect.ValueOf(elem.value)
+			keys := value.MapKeys()
+			sort.Slice(keys, func(i, j int) bool {
+				return fmt.Sprintf("%v", keys[i].Interface()) < fmt.Sprintf("%v", keys[j].Interface())
+			})
+
+			for _, key := range keys {
+				queue = append(queue, queueElem{value: value.MapIndex(key).Interface(), keyPath: JoinKeyPath(elem.keyPath, fmt.Sprintf("%v", key.Interface())), nestedFormat: elem.nestedFormat, nestingDepth: elem.nestingDepth})
 			}
 		default:
-			elemStr := fmt.Sprintf("%v", elemI)
-			if len(elemStr) >= 4 {
-				maskedValues = append(maskedValues, elemStr)
+			elemStr := fmt.Sprintf("%v", elem.value)
+			if opts.Policy.ShouldMask(elem.keyPath, elem.value) {
+				secretValues = append(secretValues, newSecretValue(elemStr, elem.keyPath, elem.nestedFormat, 0))
 			}
-			for _, line := range strings.Split(elemStr, "\n") {
+			for i, line := range strings.Split(elemStr, "\n") {
 				trimmedLine := strings.TrimSpace(line)
-				if len(trimmedLine) >= 4 {
-					maskedValues = append(maskedValues, trimmedLine)
+				if opts.Policy.ShouldMaskPart(elem.keyPath, trimmedLine) {
+					secretValues = append(secretValues, newSecretValue(trimmedLine, elem.keyPath, elem.nestedFormat, i+1))
 				}
 			}
 
-			dataMap := map[string]interface{}{}
-			if err := json.Unmarshal([]byte(elemStr), &dataMap); err == nil {
-				for _, v := range dataMap {
-					queue = append(queue, v)
-				}
+			if _, isStr := elem.value.(string); !isStr || elem.nestingDepth >= opts.MaxNestingDepth {
+				continue
 			}
 
-			dataArr := []interface{}{}
-			if err := json.Unmarshal([]byte(elemStr), &dataArr); err == nil {
-				queue = append(queue, dataArr...)
+			for _, parser := range opts.NestedFormatParsers {
+				if nestedData, ok := parser.Parse(elemStr); ok {
+					queue = append(queue, queueElem{value: nestedData, keyPath: elem.keyPath, nestedFormat: parser.Name(), nestingDepth: elem.nestingDepth + 1})
+					break
+				}
 			}
 		}
 	}
 
-	return maskedValues
+	if !opts.KeepDuplicates {
+		secretValues = DeduplicateSecretValues(secretValues)
+	}
+
+	if opts.IncludeEncodedVariants {
+		secretValues = appendEncodedVariants(secretValues)
+	}
+
+	return secretValues, nil
+}
+
+// JoinKeyPath returns the path of the key in the map found by the parent path, e.g. "app.db.password".
+func JoinKeyPath(parent, key string) string {
+	if parent == "" {
+		return key
+	}
+
+	return parent + "." + key
+}
+
+// JoinKeyPathIndex returns the path of the element in the array found by the parent path, e.g. "app.hosts[0]".
+func JoinKeyPathIndex(parent string, index int) string {
+	return fmt.Sprintf("%s[%d]", parent, index)
 }
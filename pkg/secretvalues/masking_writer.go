@@ -0,0 +1,163 @@
+package secretvalues
+
+import (
+	"fmt"
+	"io"
+	"sync"
+)
+
+const DefaultMaskPlaceholder = "***"
+
+var _ io.WriteCloser = (*MaskingWriter)(nil)
+
+type MaskingWriterOptions struct {
+	// Replaces each found secret value. Defaults to DefaultMaskPlaceholder.
+	Placeholder string
+	// If set, returns the replacement for the found secret value instead of Placeholder,
+	// e.g. KeyPathPlaceholder.
+	PlaceholderFunc func(secretValue SecretValue) string
+}
+
+// MaskingWriter replaces secret values with a placeholder in everything written to it before
+// passing the data to the underlying writer. A secret value might be split across multiple
+// Write calls: the data which might be the beginning of a secret value is held back until the
+// next Write, Flush or Close. Writes after Close fail with io.ErrClosedPipe. Safe for concurrent
+// use.
+type MaskingWriter struct {
+	writer          io.Writer
+	placeholderFunc func(secretValue SecretValue) string
+
+	mu           sync.Mutex
+	secretValues []SecretValue
+	matcher      *ahoCorasickMatcher
+	pending      []byte
+	closed       bool
+}
+
+func NewMaskingWriter(writer io.Writer, secretValues []string, opts MaskingWriterOptions) *MaskingWriter {
+	w := NewMaskingWriterForSecretValues(writer, nil, opts)
+	w.AddSecretValues(secretValues...)
+
+	return w
+}
+
+func NewMaskingWriterForSecretValues(writer io.Writer, secretValues []SecretValue, opts MaskingWriterOptions) *MaskingWriter {
+	if opts.Placeholder == "" {
+		opts.Placeholder = DefaultMaskPlaceholder
+	}
+
+	if opts.PlaceholderFunc == nil {
+		opts.PlaceholderFunc = func(_ SecretValue) string { return opts.Placeholder }
+	}
+
+	w := &MaskingWriter{
+		writer:          writer,
+		placeholderFunc: opts.PlaceholderFunc,
+	}
+	w.AddExtractedSecretValues(secretValues...)
+
+	return w
+}
+
+// AddSecretValues registers more secret values to mask in the data written after this call.
+func (w *MaskingWriter) AddSecretValues(secretValues ...string) {
+	extractedSecretValues := make([]SecretValue, 0, len(secretValues))
+	for _, value := range secretValues {
+		extractedSecretValues = append(extractedSecretValues, SecretValue{Value: value})
+	}
+
+	w.AddExtractedSecretValues(extractedSecretValues...)
+}
+
+// AddExtractedSecretValues is like AddSecretValues, but for the values with provenance. If the
+// same value is added multiple times, the first one is used for the placeholder.
+func (w *MaskingWriter) AddExtractedSecretValues(secretValues ...SecretValue) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for _, secretValue := range secretValues {
+		if secretValue.Value != "" {
+			w.secretValues = append(w.secretValues, secretValue)
+		}
+	}
+	w.secretValues = DeduplicateSecretValues(w.secretValues)
+
+	patterns := make([]string, 0, len(w.secretValues))
+	for _, secretValue := range w.secretValues {
+		patterns = append(patterns, secretValue.Value)
+	}
+
+	w.matcher = newAhoCorasickMatcher(patterns)
+}
+
+func (w *MaskingWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return 0, io.ErrClosedPipe
+	}
+
+	if err := w.mask(p, false); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// Flush writes the held back data to the underlying writer.
+func (w *MaskingWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.mask(nil, true)
+}
+
+// Close flushes the held back data and closes the underlying writer if it is an io.Closer.
+// Closing an already closed writer does nothing.
+func (w *MaskingWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+
+	if err := w.mask(nil, true); err != nil {
+		return err
+	}
+
+	if closer, ok := w.writer.(io.Closer); ok {
+		return closer.Close()
+	}
+
+	return nil
+}
+
+func (w *MaskingWriter) mask(p []byte, final bool) error {
+	data := append(w.pending, p...)
+
+	matches, offset := w.matcher.Find(data, final)
+
+	result := make([]byte, 0, offset)
+	prevEnd := 0
+	for _, match := range matches {
+		result = append(result, data[prevEnd:match.Start]...)
+		result = append(result, w.placeholderFunc(w.secretValues[match.Pattern])...)
+		prevEnd = match.End
+	}
+	result = append(result, data[prevEnd:offset]...)
+
+	w.pending = append([]byte(nil), data[offset:]...)
+
+	if len(result) == 0 {
+		return nil
+	}
+
+	if _, err := w.writer.Write(result); err != nil {
+		return fmt.Errorf("write masked data: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,145 @@
+package secretvalues
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("MaskingWriter", func() {
+	DescribeTable("should mask secret values regardless of how the data is split into writes",
+		func(secretValues []string, input, expected string) {
+			for chunkSize := 1; chunkSize <= len(input); chunkSize++ {
+				var buf bytes.Buffer
+				w := NewMaskingWriter(&buf, secretValues, MaskingWriterOptions{})
+
+				for i := 0; i < len(input); i += chunkSize {
+					n, err := w.Write([]byte(input[i:min(i+chunkSize, len(input))]))
+					Expect(err).To(Succeed())
+					Expect(n).To(Equal(min(chunkSize, len(input)-i)))
+				}
+				Expect(w.Close()).To(Succeed())
+
+				Expect(buf.String()).To(Equal(expected), fmt.Sprintf("chunk size %d", chunkSize))
+			}
+		},
+		Entry("no secret values", nil, "password: qwerty", "password: qwerty"),
+		Entry("single value", []string{"qwerty"}, "password: qwerty\n", "password: ***\n"),
+		Entry("repeated value", []string{"qwerty"}, "qwertyqwerty qwerty", "****** ***"),
+		Entry("longest value wins", []string{"abcd", "abcdef"}, "xabcdefx abcdex", "x***x ***ex"),
+		Entry("leftmost value wins", []string{"bcdef", "abcd"}, "abcdef", "***ef"),
+		Entry("overlapping prefix", []string{"aab"}, "aaab", "a***"),
+		Entry("unfinished value", []string{"secret"}, "my secre", "my secre"),
+		Entry("multiline value", []string{"line1\nline2"}, "key: |\nline1\nline2\n", "key: |\n***\n"),
+	)
+
+	It("should use custom placeholder", func() {
+		var buf bytes.Buffer
+		w := NewMaskingWriter(&buf, []string{"qwerty"}, MaskingWriterOptions{Placeholder: "[MASKED]"})
+
+		_, err := w.Write([]byte("password: qwerty"))
+		Expect(err).To(Succeed())
+		Expect(w.Flush()).To(Succeed())
+
+		Expect(buf.String()).To(Equal("password: [MASKED]"))
+	})
+
+	It("should pass data through immediately when it can't be a part of a secret value", func() {
+		var buf bytes.Buffer
+		w := NewMaskingWriter(&buf, []string{"qwerty"}, MaskingWriterOptions{})
+
+		_, err := w.Write([]byte("line without secrets\n"))
+		Expect(err).To(Succeed())
+		Expect(buf.String()).To(Equal("line without secrets\n"))
+
+		_, err = w.Write([]byte("password: qwe"))
+		Expect(err).To(Succeed())
+		Expect(buf.String()).To(Equal("line without secrets\npassword: "))
+
+		_, err = w.Write([]byte("rty\n"))
+		Expect(err).To(Succeed())
+		Expect(buf.String()).To(Equal("line without secrets\npassword: ***\n"))
+	})
+
+	It("should fail writes after close and close only once", func() {
+		out := &countingCloser{}
+		w := NewMaskingWriter(out, []string{"qwerty"}, MaskingWriterOptions{})
+
+		_, err := w.Write([]byte("password: qwe"))
+		Expect(err).To(Succeed())
+		Expect(w.Close()).To(Succeed())
+		Expect(w.Close()).To(Succeed())
+		Expect(out.closes).To(Equal(1))
+
+		_, err = w.Write([]byte("rty"))
+		Expect(err).To(MatchError(io.ErrClosedPipe))
+		Expect(w.Flush()).To(Succeed())
+		Expect(out.String()).To(Equal("password: qwe"))
+	})
+
+	It("should mask secret values added after creation", func() {
+		var buf bytes.Buffer
+		w := NewMaskingWriter(&buf, []string{"first"}, MaskingWriterOptions{})
+		w.AddSecretValues("second", "first", "")
+
+		_, err := w.Write([]byte("first second third"))
+		Expect(err).To(Succeed())
+		Expect(w.Flush()).To(Succeed())
+
+		Expect(buf.String()).To(Equal("*** *** third"))
+	})
+
+	It("should mask hundreds of secret values", func() {
+		var secretValues []string
+		var input, expected bytes.Buffer
+		for i := 0; i < 500; i++ {
+			secretValues = append(secretValues, fmt.Sprintf("secret-%03d", i))
+			fmt.Fprintf(&input, "value %d: secret-%03d\n", i, i)
+			fmt.Fprintf(&expected, "value %d: ***\n", i)
+		}
+
+		var buf bytes.Buffer
+		w := NewMaskingWriter(&buf, secretValues, MaskingWriterOptions{})
+
+		_, err := w.Write(input.Bytes())
+		Expect(err).To(Succeed())
+		Expect(w.Flush()).To(Succeed())
+
+		Expect(buf.String()).To(Equal(expected.String()))
+	})
+})
+
+var _ = Describe("MaskingWriter with extracted secret values", func() {
+	It("should replace secret values with their key paths", func() {
+		secretValues, err := ExtractSecretValuesWithKeyPaths(map[string]interface{}{
+			"app": map[string]interface{}{
+				"db":     map[string]interface{}{"password": "qwerty"},
+				"config": `{"token": "s3cr3t-t0k3n"}`,
+			},
+		}, ExtractSecretValuesOptions{})
+		Expect(err).NotTo(HaveOccurred())
+
+		var buf bytes.Buffer
+		w := NewMaskingWriterForSecretValues(&buf, secretValues, MaskingWriterOptions{PlaceholderFunc: KeyPathPlaceholder})
+		w.AddSecretValues("unknown")
+
+		_, err = w.Write([]byte("connecting with qwerty using s3cr3t-t0k3n and unknown"))
+		Expect(err).To(Succeed())
+		Expect(w.Flush()).To(Succeed())
+
+		Expect(buf.String()).To(Equal("connecting with ***app.db.password*** using ***app.config.token*** and ***"))
+	})
+})
+
+type countingCloser struct {
+	bytes.Buffer
+	closes int
+}
+
+func (c *countingCloser) Close() error {
+	c.closes++
+	return nil
+}
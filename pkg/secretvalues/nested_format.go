@@ -0,0 +1,143 @@
+package secretvalues
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	yaml_v3 "gopkg.in/yaml.v3"
+
+	"github.com/werf/common-go/pkg/util"
+)
+
+const DefaultMaxNestingDepth = 10
+
+var (
+	_ NestedFormatParser = JSONNestedFormatParser{}
+	_ NestedFormatParser = YAMLNestedFormatParser{}
+	_ NestedFormatParser = DotenvNestedFormatParser{}
+	_ NestedFormatParser = Base64NestedFormatParser{}
+)
+
+// NestedFormatParser finds documents stored in string values, so that secret values in them are
+// extracted too, along with their key paths.
+type NestedFormatParser interface {
+	// Name is used as SecretValue.NestedFormat.
+	Name() string
+	// Parse returns the parsed document (a map, a slice or a string) or false if the value is
+	// not in this format.
+	Parse(value string) (interface{}, bool)
+}
+
+// DefaultNestedFormatParsers returns the parsers all extract functions use by default, which are
+// all available parsers, see AllNestedFormatParsers. Nesting is limited by
+// ExtractSecretValuesOptions.MaxNestingDepth.
+func DefaultNestedFormatParsers() []NestedFormatParser {
+	return AllNestedFormatParsers()
+}
+
+// AllNestedFormatParsers returns all available parsers. YAML is parsed after JSON, so that JSON
+// documents are reported as JSON, and dotenv after base64, because base64 padding looks like
+// "KEY=".
+func AllNestedFormatParsers() []NestedFormatParser {
+	return []NestedFormatParser{
+		JSONNestedFormatParser{},
+		YAMLNestedFormatParser{},
+		Base64NestedFormatParser{},
+		DotenvNestedFormatParser{},
+	}
+}
+
+// JSONNestedFormatParser parses JSON objects and arrays.
+type JSONNestedFormatParser struct{}
+
+func (p JSONNestedFormatParser) Name() string {
+	return "json"
+}
+
+func (p JSONNestedFormatParser) Parse(value string) (interface{}, bool) {
+	dataMap := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(value), &dataMap); err == nil {
+		return dataMap, true
+	}
+
+	dataArr := []interface{}{}
+	if err := json.Unmarshal([]byte(value), &dataArr); err == nil {
+		return dataArr, true
+	}
+
+	return nil, false
+}
+
+// YAMLNestedFormatParser parses YAML mappings and sequences, e.g. application.yaml stored in a value.
+type YAMLNestedFormatParser struct{}
+
+func (p YAMLNestedFormatParser) Name() string {
+	return "yaml"
+}
+
+func (p YAMLNestedFormatParser) Parse(value string) (interface{}, bool) {
+	var data interface{}
+	if err := yaml_v3.Unmarshal([]byte(value), &data); err != nil || data == nil {
+		return nil, false
+	}
+
+	switch reflect.TypeOf(data).Kind() {
+	case reflect.Slice, reflect.Map:
+		return data, true
+	}
+
+	return nil, false
+}
+
+// DotenvNestedFormatParser parses dotenv files and other files with "KEY=value" lines, see
+// util.ParseDotenv.
+type DotenvNestedFormatParser struct{}
+
+func (p DotenvNestedFormatParser) Name() string {
+	return "dotenv"
+}
+
+func (p DotenvNestedFormatParser) Parse(value string) (interface{}, bool) {
+	vars, err := util.ParseDotenv(strings.NewReader(value))
+	if err != nil || len(vars) == 0 {
+		return nil, false
+	}
+
+	data := make(map[string]interface{}, len(vars))
+	for name, value := range vars {
+		data[name] = value
+	}
+
+	return data, true
+}
+
+// Base64NestedFormatParser decodes base64-encoded printable text. Arbitrary binary data is not
+// returned, because short strings like "abcd" are valid base64 too.
+type Base64NestedFormatParser struct{}
+
+func (p Base64NestedFormatParser) Name() string {
+	return "base64"
+}
+
+func (p Base64NestedFormatParser) Parse(value string) (interface{}, bool) {
+	if len(value) < 4 {
+		return nil, false
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(value)
+	if err != nil || len(decoded) == 0 || !utf8.Valid(decoded) {
+		return nil, false
+	}
+
+	for _, r := range string(decoded) {
+		if !unicode.IsPrint(r) && !unicode.IsSpace(r) {
+			return nil, false
+		}
+	}
+
+	return string(decoded), true
+}
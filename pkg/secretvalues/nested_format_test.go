@@ -0,0 +1,84 @@
+package secretvalues
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("NestedFormatParser", func() {
+	DescribeTable("should parse nested documents of the supported formats",
+		func(parser NestedFormatParser, value string, expected interface{}) {
+			data, ok := parser.Parse(value)
+			if expected == nil {
+				Expect(ok).To(BeFalse())
+				return
+			}
+
+			Expect(ok).To(BeTrue())
+			Expect(data).To(Equal(expected))
+		},
+		Entry("json object", JSONNestedFormatParser{}, `{"a": "b"}`, map[string]interface{}{"a": "b"}),
+		Entry("json array", JSONNestedFormatParser{}, `["a"]`, []interface{}{"a"}),
+		Entry("json scalar", JSONNestedFormatParser{}, `"a"`, nil),
+		Entry("yaml mapping", YAMLNestedFormatParser{}, "a:\n  b: c\n", map[string]interface{}{"a": map[string]interface{}{"b": "c"}}),
+		Entry("yaml scalar", YAMLNestedFormatParser{}, "just a string", nil),
+		Entry("dotenv", DotenvNestedFormatParser{}, "# comment\nexport A=1\nB = \"two\\nlines\"\nC='three' \nD=four # comment\n", map[string]interface{}{
+			"A": "1",
+			"B": "two\nlines",
+			"C": "three",
+			"D": "four",
+		}),
+		Entry("not dotenv", DotenvNestedFormatParser{}, "A=1\nnot a pair\n", nil),
+		Entry("empty dotenv", DotenvNestedFormatParser{}, "# comment only\n", nil),
+		Entry("base64 text", Base64NestedFormatParser{}, "c2VjcmV0", "secret"),
+		Entry("base64 binary", Base64NestedFormatParser{}, "AAECAw==", nil),
+		Entry("not base64", Base64NestedFormatParser{}, "secret", nil),
+	)
+})
+
+var _ = Describe("ExtractSecretValuesWithKeyPaths with nested formats", func() {
+	data := map[string]interface{}{
+		"app": map[string]interface{}{
+			"applicationYaml": "spring:\n  datasource:\n    password: qwerty\n",
+			"env":             "DB_USER=admin\nDB_PASSWORD=\"s3cr3t\"\n",
+		},
+	}
+
+	It("should extract secret values from nested documents", func() {
+		secretValues, err := ExtractSecretValuesWithKeyPaths(data, ExtractSecretValuesOptions{})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(secretValues).To(ContainElements(
+			SecretValue{Value: "qwerty", KeyPath: "app.applicationYaml.spring.datasource.password", Origin: SecretValueOriginValue, NestedFormat: "yaml", ID: "app.applicationYaml.spring.datasource.password#yaml"},
+			SecretValue{Value: "s3cr3t", KeyPath: "app.env.DB_PASSWORD", Origin: SecretValueOriginValue, NestedFormat: "dotenv", ID: "app.env.DB_PASSWORD#dotenv"},
+		))
+	})
+
+	It("should parse nested documents of all formats by default", func() {
+		Expect(ExtractSecretValuesFromMap(data)).To(ContainElements("qwerty", "admin", "s3cr3t"))
+
+		Expect(ExtractSecretValuesFromMapWithOptions(data, ExtractSecretValuesOptions{
+			NestedFormatParsers: []NestedFormatParser{JSONNestedFormatParser{}},
+		})).NotTo(ContainElement("qwerty"))
+	})
+
+	It("should not parse documents nested deeper than the limit", func() {
+		// base64(base64("deep-secret"))
+		data := map[string]interface{}{"value": "WkdWbGNDMXpaV055WlhRPQ=="}
+
+		Expect(ExtractSecretValuesFromMapWithOptions(data, ExtractSecretValuesOptions{
+			NestedFormatParsers: []NestedFormatParser{Base64NestedFormatParser{}},
+		})).To(ContainElement("deep-secret"))
+
+		Expect(ExtractSecretValuesFromMapWithOptions(data, ExtractSecretValuesOptions{
+			NestedFormatParsers: []NestedFormatParser{Base64NestedFormatParser{}},
+			MaxNestingDepth:     1,
+		})).To(Equal([]string{"WkdWbGNDMXpaV055WlhRPQ==", "ZGVlcC1zZWNyZXQ="}))
+
+		Expect(ExtractSecretValuesFromMapWithOptions(data, ExtractSecretValuesOptions{
+			NestedFormatParsers: []NestedFormatParser{Base64NestedFormatParser{}},
+			MaxNestingDepth:     1,
+			KeepDuplicates:      true,
+		})).To(Equal([]string{"WkdWbGNDMXpaV055WlhRPQ==", "WkdWbGNDMXpaV055WlhRPQ==", "ZGVlcC1zZWNyZXQ=", "ZGVlcC1zZWNyZXQ="}))
+	})
+})
@@ -0,0 +1,187 @@
+package secretvalues
+
+import (
+	"fmt"
+	"math"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// MaskPolicy decides whether a value found by a key path should be masked.
+//
+// Values shorter than MinLength are never masked, even under AlwaysMaskKeys, since masking a
+// short value like "1" would mangle every occurrence of it in the output. Otherwise key path
+// rules have the highest priority: a value under a key matching AlwaysMaskKeys is always
+// masked, otherwise a value under a key matching NeverMaskKeys is never masked. Other values
+// are masked if they are not excluded and, if MinEntropy is set, look random enough.
+type MaskPolicy struct {
+	// Values shorter than this are not masked, whatever the other rules are.
+	MinLength int
+
+	// Values which are never masked, e.g. common words like "enabled".
+	ExcludeValues []string
+	// Do not mask booleans and strings "true" and "false".
+	ExcludeBooleans bool
+	// Do not mask numbers and numeric strings.
+	ExcludeNumbers bool
+	// Do not mask strings "null" and "~".
+	ExcludeNulls bool
+
+	// Glob patterns (see path.Match) matched case-insensitively against the full key path
+	// (e.g. "app.db.password") and against the last key of the path (e.g. "password").
+	AlwaysMaskKeys []string
+	NeverMaskKeys  []string
+
+	// If greater than zero, values not matched by key path rules are masked only if their
+	// Shannon entropy in bits per byte is at least this high. Random tokens and generated
+	// passwords usually have 3.5 or more, while words and identifiers have less.
+	MinEntropy float64
+}
+
+// DefaultMaskPolicy returns the policy ExtractSecretValuesFromMap uses: every value of at
+// least 4 characters is masked.
+func DefaultMaskPolicy() *MaskPolicy {
+	return &MaskPolicy{MinLength: 4}
+}
+
+// Validate returns an error if any of the key path patterns is malformed.
+func (p *MaskPolicy) Validate() error {
+	for _, pattern := range append(append([]string{}, p.AlwaysMaskKeys...), p.NeverMaskKeys...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("bad key path pattern %q: %w", pattern, err)
+		}
+	}
+
+	return nil
+}
+
+func (p *MaskPolicy) ShouldMask(keyPath string, value interface{}) bool {
+	return p.shouldMask(keyPath, value, false)
+}
+
+// ShouldMaskPart is like ShouldMask, but for a part of the value (e.g. a line of a multiline
+// value). AlwaysMaskKeys do not apply to parts, so that short lines like "{" are not masked.
+func (p *MaskPolicy) ShouldMaskPart(keyPath string, part string) bool {
+	return p.shouldMask(keyPath, part, true)
+}
+
+func (p *MaskPolicy) shouldMask(keyPath string, value interface{}, isPart bool) bool {
+	valueStr := fmt.Sprintf("%v", value)
+	if valueStr == "" {
+		return false
+	}
+
+	if len(valueStr) < p.MinLength {
+		return false
+	}
+
+	if !isPart && matchKeyPath(p.AlwaysMaskKeys, keyPath) {
+		return true
+	}
+
+	if matchKeyPath(p.NeverMaskKeys, keyPath) {
+		return false
+	}
+
+	if p.isExcluded(value, valueStr) {
+		return false
+	}
+
+	if p.MinEntropy > 0 && ShannonEntropy(valueStr) < p.MinEntropy {
+		return false
+	}
+
+	return true
+}
+
+func (p *MaskPolicy) isExcluded(value interface{}, valueStr string) bool {
+	for _, excludedValue := range p.ExcludeValues {
+		if valueStr == excludedValue {
+			return true
+		}
+	}
+
+	if p.ExcludeBooleans {
+		if _, isBool := value.(bool); isBool || valueStr == "true" || valueStr == "false" {
+			return true
+		}
+	}
+
+	if p.ExcludeNumbers {
+		switch reflect.TypeOf(value).Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+			reflect.Float32, reflect.Float64:
+			return true
+		}
+
+		if _, err := strconv.ParseFloat(valueStr, 64); err == nil {
+			return true
+		}
+	}
+
+	if p.ExcludeNulls && (valueStr == "null" || valueStr == "~") {
+		return true
+	}
+
+	return false
+}
+
+func matchKeyPath(patterns []string, keyPath string) bool {
+	if keyPath == "" {
+		return false
+	}
+
+	keyPath = strings.ToLower(keyPath)
+
+	// Elements of arrays are matched by the key of the array: "passwords[0]" -> "passwords".
+	lastKey := keyPath
+	for strings.HasSuffix(lastKey, "]") {
+		i := strings.LastIndex(lastKey, "[")
+		if i == -1 {
+			break
+		}
+		lastKey = lastKey[:i]
+	}
+	lastKey = lastKey[strings.LastIndex(lastKey, ".")+1:]
+
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(pattern)
+
+		if matched, _ := path.Match(pattern, keyPath); matched {
+			return true
+		}
+
+		if matched, _ := path.Match(pattern, lastKey); matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ShannonEntropy returns the Shannon entropy of the string in bits per byte.
+func ShannonEntropy(s string) float64 {
+	if s == "" {
+		return 0
+	}
+
+	var counts [256]int
+	for i := 0; i < len(s); i++ {
+		counts[s[i]]++
+	}
+
+	var entropy float64
+	for _, count := range counts {
+		if count == 0 {
+			continue
+		}
+
+		p := float64(count) / float64(len(s))
+		entropy -= p * math.Log2(p)
+	}
+
+	return entropy
+}
@@ -0,0 +1,94 @@
+package secretvalues
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("MaskPolicy", func() {
+	policy := &MaskPolicy{
+		MinLength:       4,
+		ExcludeValues:   []string{"enabled"},
+		ExcludeBooleans: true,
+		ExcludeNumbers:  true,
+		ExcludeNulls:    true,
+		AlwaysMaskKeys:  []string{"*password*", "app.token"},
+		NeverMaskKeys:   []string{"replicas", "*.image"},
+	}
+
+	DescribeTable("ShouldMask",
+		func(keyPath string, value interface{}, expected bool) {
+			Expect(policy.ShouldMask(keyPath, value)).To(Equal(expected))
+		},
+		Entry("regular value", "app.secret", "qwerty", true),
+		Entry("short value", "app.secret", "abc", false),
+		Entry("empty value", "app.password", "", false),
+		Entry("excluded value", "app.mode", "enabled", false),
+		Entry("bool", "app.debug", true, false),
+		Entry("bool string", "app.debug", "false", false),
+		Entry("number", "app.port", 12345, false),
+		Entry("float", "app.ratio", 0.75, false),
+		Entry("numeric string", "app.port", "12345", false),
+		Entry("null string", "app.value", "null", false),
+		Entry("always masked key", "app.db.dbPassword", "abcd", true),
+		Entry("always masked key with short value", "app.db.dbPassword", "1", false),
+		Entry("always masked key with excluded value", "app.password", "enabled", true),
+		Entry("always masked key of array", "app.passwords[1]", "1234", true),
+		Entry("always masked full key path", "app.token", "true", true),
+		Entry("never masked key", "app.replicas", "10000", false),
+		Entry("never masked key glob", "app.image", "registry.example.com/app", false),
+		Entry("always masked key has priority", "image.password", "qwerty", true),
+	)
+
+	It("should not apply always masked keys to parts of values", func() {
+		Expect(policy.ShouldMaskPart("app.password", "{")).To(BeFalse())
+		Expect(policy.ShouldMaskPart("app.password", "line of a password")).To(BeTrue())
+		Expect(policy.ShouldMaskPart("replicas", "line of replicas")).To(BeFalse())
+	})
+
+	It("should mask only high-entropy values if MinEntropy is set", func() {
+		entropyPolicy := &MaskPolicy{MinLength: 4, MinEntropy: 3.5, AlwaysMaskKeys: []string{"password"}}
+
+		Expect(entropyPolicy.ShouldMask("app.name", "application")).To(BeFalse())
+		Expect(entropyPolicy.ShouldMask("app.token", "xK9#mQ2$vL7pZ4w")).To(BeTrue())
+		Expect(entropyPolicy.ShouldMask("app.password", "aaaa")).To(BeTrue())
+	})
+
+	It("should report malformed key path patterns", func() {
+		Expect((&MaskPolicy{AlwaysMaskKeys: []string{"[password"}}).Validate()).NotTo(Succeed())
+		Expect(policy.Validate()).To(Succeed())
+	})
+})
+
+var _ = Describe("ExtractSecretValuesFromMapWithOptions with MaskPolicy", func() {
+	It("should mask values according to the policy", func() {
+		values, err := ExtractSecretValuesFromMapWithOptions(map[string]interface{}{
+			"replicas": 3,
+			"enabled":  true,
+			"app": map[string]interface{}{
+				"name":     "backend",
+				"password": "1234",
+				"pin":      "12",
+				"config":   `{"token": "s3cr3t-t0k3n", "port": 8080}`,
+			},
+		}, ExtractSecretValuesOptions{
+			Policy: &MaskPolicy{
+				MinLength:       4,
+				ExcludeBooleans: true,
+				ExcludeNumbers:  true,
+				AlwaysMaskKeys:  []string{"*password*", "pin"},
+				NeverMaskKeys:   []string{"name"},
+			},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(values).To(ConsistOf("1234", `{"token": "s3cr3t-t0k3n", "port": 8080}`, "s3cr3t-t0k3n"))
+	})
+
+	It("should fail on a malformed key path pattern", func() {
+		_, err := ExtractSecretValuesFromMapWithOptions(map[string]interface{}{"password": "qwerty"}, ExtractSecretValuesOptions{
+			Policy: &MaskPolicy{AlwaysMaskKeys: []string{"[password"}},
+		})
+		Expect(err).To(MatchError(ContainSubstring(`validate mask policy: bad key path pattern "[password"`)))
+	})
+})
@@ -0,0 +1,209 @@
+package secretvalues
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/werf/common-go/pkg/util"
+)
+
+const scanChunkSize = 64 * 1024
+
+type ScannerOptions struct {
+	// Also look for encoded variants of the secret values (see EncodedVariants).
+	IncludeEncodedVariants bool
+	// Glob patterns (see filepath.Match) of the file paths, relative to the scanned directory or
+	// archive root, which are not scanned.
+	ExcludePaths []string
+}
+
+// ScanFinding is an occurrence of a known secret value in a file.
+type ScanFinding struct {
+	// Path of the file relative to the scanned directory or archive root.
+	Path string
+	// Number of the line, starting from 1, where the secret value begins.
+	Line        int
+	SecretValue SecretValue
+}
+
+// String describes the finding without revealing the secret value.
+func (f ScanFinding) String() string {
+	if f.SecretValue.ID == "" {
+		return fmt.Sprintf("%s:%d: secret value found", f.Path, f.Line)
+	}
+
+	return fmt.Sprintf("%s:%d: secret value %q found", f.Path, f.Line, f.SecretValue.ID)
+}
+
+// Scanner finds files containing known secret values, e.g. to make sure that rendered manifests
+// or build artifacts do not contain plaintext secrets before they are published.
+type Scanner struct {
+	secretValues []SecretValue
+	matcher      *ahoCorasickMatcher
+	excludePaths []string
+}
+
+func NewScanner(secretValues []SecretValue, opts ScannerOptions) *Scanner {
+	var nonEmptySecretValues []SecretValue
+	for _, secretValue := range secretValues {
+		if secretValue.Value != "" {
+			nonEmptySecretValues = append(nonEmptySecretValues, secretValue)
+		}
+	}
+
+	if opts.IncludeEncodedVariants {
+		nonEmptySecretValues = appendEncodedVariants(nonEmptySecretValues)
+	}
+	nonEmptySecretValues = DeduplicateSecretValues(nonEmptySecretValues)
+
+	patterns := make([]string, 0, len(nonEmptySecretValues))
+	for _, secretValue := range nonEmptySecretValues {
+		patterns = append(patterns, secretValue.Value)
+	}
+
+	return &Scanner{
+		secretValues: nonEmptySecretValues,
+		matcher:      newAhoCorasickMatcher(patterns),
+		excludePaths: opts.ExcludePaths,
+	}
+}
+
+// ScanDir scans all regular files in the directory recursively. Symlinks are not followed.
+func (s *Scanner) ScanDir(ctx context.Context, dir string) ([]ScanFinding, error) {
+	pipeReader, pipeWriter := io.Pipe()
+	go func() {
+		pipeWriter.CloseWithError(util.WriteDirAsTar(dir, pipeWriter))
+	}()
+	defer pipeReader.Close()
+
+	findings, err := s.ScanTar(ctx, pipeReader)
+	if err != nil {
+		return nil, fmt.Errorf("scan dir %q: %w", dir, err)
+	}
+
+	return findings, nil
+}
+
+// ScanTarFile scans all regular files in the tar archive.
+func (s *Scanner) ScanTarFile(ctx context.Context, archivePath string) ([]ScanFinding, error) {
+	file, err := os.Open(archivePath)
+	if err != nil {
+		return nil, fmt.Errorf("open %q: %w", archivePath, err)
+	}
+	defer file.Close()
+
+	findings, err := s.ScanTar(ctx, file)
+	if err != nil {
+		return nil, fmt.Errorf("scan archive %q: %w", archivePath, err)
+	}
+
+	return findings, nil
+}
+
+// ScanTar scans all regular files in the tar stream.
+func (s *Scanner) ScanTar(ctx context.Context, in io.Reader) ([]ScanFinding, error) {
+	var findings []ScanFinding
+
+	tr := tar.NewReader(in)
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("read archive: %w", err)
+		}
+
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		entryPath := filepath.ToSlash(filepath.Clean(hdr.Name))
+
+		excluded, err := s.isExcluded(entryPath)
+		if err != nil {
+			return nil, err
+		}
+		if excluded {
+			continue
+		}
+
+		entryFindings, err := s.ScanReader(entryPath, tr)
+		if err != nil {
+			return nil, err
+		}
+
+		findings = append(findings, entryFindings...)
+	}
+
+	return findings, nil
+}
+
+// ScanReader scans the content of a single file, which is reported with the specified path.
+func (s *Scanner) ScanReader(path string, in io.Reader) ([]ScanFinding, error) {
+	var findings []ScanFinding
+
+	var pending []byte
+	chunk := make([]byte, scanChunkSize)
+	// Number of the line at the beginning of pending.
+	line := 1
+
+	for {
+		n, readErr := in.Read(chunk)
+		if readErr != nil && !errors.Is(readErr, io.EOF) {
+			return nil, fmt.Errorf("read %q: %w", path, readErr)
+		}
+
+		data := append(pending, chunk[:n]...)
+		final := errors.Is(readErr, io.EOF)
+
+		matches, offset := s.matcher.Find(data, final)
+
+		prevStart := 0
+		for _, match := range matches {
+			line += bytes.Count(data[prevStart:match.Start], []byte("\n"))
+			prevStart = match.Start
+
+			findings = append(findings, ScanFinding{
+				Path:        path,
+				Line:        line,
+				SecretValue: s.secretValues[match.Pattern],
+			})
+		}
+
+		// The data after offset is scanned again along with the next chunk.
+		line += bytes.Count(data[prevStart:offset], []byte("\n"))
+
+		if final {
+			break
+		}
+
+		pending = append(pending[:0:0], data[offset:]...)
+	}
+
+	return findings, nil
+}
+
+func (s *Scanner) isExcluded(path string) (bool, error) {
+	for _, pattern := range s.excludePaths {
+		matched, err := filepath.Match(filepath.ToSlash(pattern), path)
+		if err != nil {
+			return false, fmt.Errorf("match exclude path pattern %q: %w", pattern, err)
+		}
+
+		if matched {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
@@ -0,0 +1,86 @@
+package secretvalues
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/werf/common-go/pkg/util"
+)
+
+var _ = Describe("Scanner", func() {
+	secretValues := []SecretValue{
+		{Value: "qwerty", KeyPath: "app.db.password", ID: "app.db.password"},
+		{Value: "s3cr3t-t0k3n", KeyPath: "app.token", ID: "app.token"},
+	}
+
+	var dir string
+
+	BeforeEach(func() {
+		dir = GinkgoT().TempDir()
+
+		writeFile := func(path, content string) {
+			Expect(os.MkdirAll(filepath.Dir(filepath.Join(dir, path)), 0o755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(dir, path), []byte(content), 0o644)).To(Succeed())
+		}
+
+		writeFile("clean.yaml", "password: '***'\n")
+		writeFile("manifests/deployment.yaml", "env:\n- name: PASSWORD\n  value: qwerty\n")
+		writeFile("manifests/secret.yaml", "data:\n  password: "+base64.StdEncoding.EncodeToString([]byte("qwerty"))+"\n")
+		writeFile("logs/build.log", "token s3cr3t-t0k3n\n")
+		Expect(os.Symlink("manifests/deployment.yaml", filepath.Join(dir, "link.yaml"))).To(Succeed())
+	})
+
+	It("should find secret values in a directory", func() {
+		findings, err := NewScanner(secretValues, ScannerOptions{}).ScanDir(context.Background(), dir)
+		Expect(err).To(Succeed())
+
+		Expect(findings).To(Equal([]ScanFinding{
+			{Path: "logs/build.log", Line: 1, SecretValue: secretValues[1]},
+			{Path: "manifests/deployment.yaml", Line: 3, SecretValue: secretValues[0]},
+		}))
+		Expect(findings[1].String()).To(Equal(`manifests/deployment.yaml:3: secret value "app.db.password" found`))
+	})
+
+	It("should find encoded variants and skip excluded paths", func() {
+		findings, err := NewScanner(secretValues, ScannerOptions{
+			IncludeEncodedVariants: true,
+			ExcludePaths:           []string{"logs/*"},
+		}).ScanDir(context.Background(), dir)
+		Expect(err).To(Succeed())
+
+		Expect(findings).To(HaveLen(2))
+		Expect(findings[0].Path).To(Equal("manifests/deployment.yaml"))
+		Expect(findings[1].Path).To(Equal("manifests/secret.yaml"))
+		Expect(findings[1].Line).To(Equal(2))
+		Expect(findings[1].SecretValue.ID).To(Equal("app.db.password#base64"))
+	})
+
+	It("should find secret values in a tar archive", func() {
+		var archive bytes.Buffer
+		Expect(util.WriteDirAsTar(dir, &archive)).To(Succeed())
+
+		findings, err := NewScanner(secretValues, ScannerOptions{}).ScanTar(context.Background(), &archive)
+		Expect(err).To(Succeed())
+		Expect(findings).To(HaveLen(2))
+	})
+
+	It("should find secret values split between read chunks", func() {
+		content := strings.Repeat("x\n", scanChunkSize/2-2) + "abqwerty\nqwerty"
+
+		findings, err := NewScanner(secretValues, ScannerOptions{}).ScanReader("file", strings.NewReader(content))
+		Expect(err).To(Succeed())
+
+		line := scanChunkSize/2 - 1
+		Expect(findings).To(Equal([]ScanFinding{
+			{Path: "file", Line: line, SecretValue: secretValues[0]},
+			{Path: "file", Line: line + 1, SecretValue: secretValues[0]},
+		}))
+	})
+})
@@ -0,0 +1,84 @@
+package secretvalues
+
+import (
+	"fmt"
+	"strings"
+)
+
+type SecretValueOrigin string
+
+const (
+	// The whole value found by the key path.
+	SecretValueOriginValue SecretValueOrigin = "value"
+	// A line of the multiline value found by the key path.
+	SecretValueOriginLine SecretValueOrigin = "line"
+	// An encoded form of another secret value, see EncodedVariants.
+	SecretValueOriginEncodedVariant SecretValueOrigin = "encoded-variant"
+)
+
+// SecretValue is a value to mask along with where it was found.
+type SecretValue struct {
+	Value string
+	// Path of the key the value was found by, e.g. "app.db.password". Keys of nested documents
+	// are part of the path too.
+	KeyPath string
+	Origin  SecretValueOrigin
+	// Format of the innermost nested document the value was found in (e.g. "json" for a value
+	// from a JSON document stored in a string value) or empty if the value is not nested.
+	NestedFormat string
+	// Number of the line, starting from 1, if Origin is SecretValueOriginLine.
+	Line int
+	// Name of the encoding, e.g. "base64", if Origin is SecretValueOriginEncodedVariant.
+	Encoding string
+	// Identifier built from the fields above, which stays the same for the same data.
+	ID string
+}
+
+func newSecretValue(value, keyPath, nestedFormat string, line int) SecretValue {
+	secretValue := SecretValue{
+		Value:        value,
+		KeyPath:      keyPath,
+		Origin:       SecretValueOriginValue,
+		NestedFormat: nestedFormat,
+		Line:         line,
+	}
+
+	idParts := []string{keyPath}
+	if nestedFormat != "" {
+		idParts = append(idParts, nestedFormat)
+	}
+	if line > 0 {
+		secretValue.Origin = SecretValueOriginLine
+		idParts = append(idParts, fmt.Sprintf("line%d", line))
+	}
+	secretValue.ID = strings.Join(idParts, "#")
+
+	return secretValue
+}
+
+// DeduplicateSecretValues removes secret values with the same Value, keeping the first one.
+func DeduplicateSecretValues(secretValues []SecretValue) []SecretValue {
+	result := make([]SecretValue, 0, len(secretValues))
+	seen := make(map[string]struct{}, len(secretValues))
+
+	for _, secretValue := range secretValues {
+		if _, ok := seen[secretValue.Value]; ok {
+			continue
+		}
+
+		seen[secretValue.Value] = struct{}{}
+		result = append(result, secretValue)
+	}
+
+	return result
+}
+
+// KeyPathPlaceholder can be used as MaskingWriterOptions.PlaceholderFunc to replace secret
+// values with their key paths, e.g. "***app.db.password***".
+func KeyPathPlaceholder(secretValue SecretValue) string {
+	if secretValue.KeyPath == "" {
+		return DefaultMaskPlaceholder
+	}
+
+	return DefaultMaskPlaceholder + secretValue.KeyPath + DefaultMaskPlaceholder
+}
@@ -0,0 +1,47 @@
+package secretvalues
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ExtractSecretValuesWithKeyPaths", func() {
+	data := map[string]interface{}{
+		"app": map[string]interface{}{
+			"cert":   "-----BEGIN-----\nMIIB\n-----END-----",
+			"config": `{"hosts": ["db.example.com"]}`,
+			"user":   "admin",
+		},
+	}
+
+	It("should return provenance of each secret value", func() {
+		secretValues, err := ExtractSecretValuesWithKeyPaths(data, ExtractSecretValuesOptions{})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(DeduplicateSecretValues(secretValues)).To(Equal([]SecretValue{
+			{Value: "-----BEGIN-----\nMIIB\n-----END-----", KeyPath: "app.cert", Origin: SecretValueOriginValue, ID: "app.cert"},
+			{Value: "-----BEGIN-----", KeyPath: "app.cert", Origin: SecretValueOriginLine, Line: 1, ID: "app.cert#line1"},
+			{Value: "MIIB", KeyPath: "app.cert", Origin: SecretValueOriginLine, Line: 2, ID: "app.cert#line2"},
+			{Value: "-----END-----", KeyPath: "app.cert", Origin: SecretValueOriginLine, Line: 3, ID: "app.cert#line3"},
+			{Value: `{"hosts": ["db.example.com"]}`, KeyPath: "app.config", Origin: SecretValueOriginValue, ID: "app.config"},
+			{Value: "admin", KeyPath: "app.user", Origin: SecretValueOriginValue, ID: "app.user"},
+			{Value: "db.example.com", KeyPath: "app.config.hosts[0]", Origin: SecretValueOriginValue, NestedFormat: "json", ID: "app.config.hosts[0]#json"},
+		}))
+	})
+
+	It("should return provenance of encoded variants", func() {
+		secretValues, err := ExtractSecretValuesWithKeyPaths(data, ExtractSecretValuesOptions{IncludeEncodedVariants: true})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(secretValues).To(ContainElements(
+			SecretValue{Value: "YWRtaW4=", KeyPath: "app.user", Origin: SecretValueOriginEncodedVariant, Encoding: "base64", ID: "app.user#base64"},
+			SecretValue{Value: "TUlJQg==", KeyPath: "app.cert", Origin: SecretValueOriginEncodedVariant, Line: 2, Encoding: "base64", ID: "app.cert#line2#base64"},
+		))
+	})
+
+	It("should return the same IDs for the same data", func() {
+		secretValues, err := ExtractSecretValuesWithKeyPaths(data, ExtractSecretValuesOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ExtractSecretValuesWithKeyPaths(data, ExtractSecretValuesOptions{})).To(Equal(secretValues))
+	})
+})
@@ -0,0 +1,13 @@
+package secretvalues
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestSecretValues(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "secretvalues suite")
+}
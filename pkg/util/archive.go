@@ -298,6 +298,31 @@ func WriteDirAsTar(dir string, w io.Writer) error {
 			return nil
 		}
 
+		if info.Mode()&os.ModeSymlink != 0 {
+			linkname, err := os.Readlink(path)
+			if err != nil {
+				return fmt.Errorf("unable to read link %q: %w", path, err)
+			}
+
+			header := &tar.Header{
+				Name:     relPath,
+				Linkname: linkname,
+				Mode:     int64(info.Mode()),
+				ModTime:  info.ModTime(),
+				Typeflag: tar.TypeSymlink,
+			}
+
+			if err := tarWriter.WriteHeader(header); err != nil {
+				return fmt.Errorf("could not tar write header for %q: %w", path, err)
+			}
+
+			if debugArchiveUtil() {
+				fmt.Printf("Written symlink %q\n", relPath)
+			}
+
+			return nil
+		}
+
 		header := &tar.Header{
 			Name:     relPath,
 			Size:     info.Size(),
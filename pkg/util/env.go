@@ -3,7 +3,6 @@ package util
 import (
 	"encoding/csv"
 	"fmt"
-	"os"
 	"sort"
 	"strconv"
 	"strings"
@@ -12,30 +11,125 @@ import (
 	"github.com/samber/lo"
 )
 
+// Env reads environment variables from the provider, e.g. a recorded environment or a .env file.
+// Package-level functions with the same names read the OS environment.
+type Env struct {
+	provider EnvProvider
+}
+
+func NewEnv(provider EnvProvider) *Env {
+	return &Env{provider: provider}
+}
+
+var osEnv = NewEnv(OSEnvProvider{})
+
 func LookupBoolEnvironment(environmentName string) (*bool, bool) {
-	value, isSet := os.LookupEnv(environmentName)
+	return osEnv.LookupBoolEnvironment(environmentName)
+}
+
+func GetBoolEnvironment(environmentName string) *bool {
+	return osEnv.GetBoolEnvironment(environmentName)
+}
+
+func GetBoolEnvironmentDefaultFalse(environmentName string) bool {
+	return osEnv.GetBoolEnvironmentDefaultFalse(environmentName)
+}
+
+func GetBoolEnvironmentDefaultTrue(environmentName string) bool {
+	return osEnv.GetBoolEnvironmentDefaultTrue(environmentName)
+}
+
+func GetFirstExistingEnvVarAsString(envNames ...string) string {
+	return osEnv.GetFirstExistingEnvVarAsString(envNames...)
+}
+
+func GetFirstExistingEnvVarAsInt(envNames ...string) (*int, error) {
+	return osEnv.GetFirstExistingEnvVarAsInt(envNames...)
+}
+
+func PredefinedValuesByEnvNamePrefix(envNamePrefix string, envNamePrefixesToExcept ...string) []string {
+	return osEnv.PredefinedValuesByEnvNamePrefix(envNamePrefix, envNamePrefixesToExcept...)
+}
+
+func GetInt64EnvVar(varName string) (*int64, error) {
+	return osEnv.GetInt64EnvVar(varName)
+}
+
+func GetIntEnvVar(varName string) (*int64, error) {
+	return osEnv.GetIntEnvVar(varName)
+}
+
+func GetIntEnvVarDefault(varName string, defaultValue int) (int, error) {
+	return osEnv.GetIntEnvVarDefault(varName, defaultValue)
+}
+
+func GetUint64EnvVar(varName string) (*uint64, error) {
+	return osEnv.GetUint64EnvVar(varName)
+}
+
+func GetIntEnvVarStrict(varName string) *int64 {
+	return osEnv.GetIntEnvVarStrict(varName)
+}
+
+func GetUint64EnvVarStrict(varName string) *uint64 {
+	return osEnv.GetUint64EnvVarStrict(varName)
+}
+
+func GetStringToStringEnvVar(varName string) (map[string]string, error) {
+	return osEnv.GetStringToStringEnvVar(varName)
+}
+
+func GetDurationEnvVar(varName string) (time.Duration, error) {
+	return osEnv.GetDurationEnvVar(varName)
+}
+
+func GetByteSizeEnvVar(varName string) (*int64, error) {
+	return osEnv.GetByteSizeEnvVar(varName)
+}
+
+func GetPercentageEnvVar(varName string) (*float64, error) {
+	return osEnv.GetPercentageEnvVar(varName)
+}
+
+func GetIntListEnvVar(varName string) ([]int, error) {
+	return osEnv.GetIntListEnvVar(varName)
+}
+
+func (e *Env) getenv(name string) string {
+	value, _ := e.provider.LookupEnv(name)
+	return value
+}
+
+func (e *Env) LookupBoolEnvironment(environmentName string) (*bool, bool) {
+	value, isSet := e.provider.LookupEnv(environmentName)
 	if !isSet {
 		return nil, false
 	}
 
+	if b, ok := parseBoolEnvValue(value); ok {
+		return &b, true
+	}
+	return nil, true
+}
+
+// Parse "1", "true", "yes" and "0", "false", "no".
+func parseBoolEnvValue(value string) (bool, bool) {
 	switch value {
 	case "1", "true", "yes":
-		t := true
-		return &t, true
+		return true, true
 	case "0", "false", "no":
-		f := false
-		return &f, true
+		return false, true
 	}
-	return nil, true
+	return false, false
 }
 
-func GetBoolEnvironment(environmentName string) *bool {
-	val, _ := LookupBoolEnvironment(environmentName)
+func (e *Env) GetBoolEnvironment(environmentName string) *bool {
+	val, _ := e.LookupBoolEnvironment(environmentName)
 	return val
 }
 
-func GetBoolEnvironmentDefaultFalse(environmentName string) bool {
-	switch os.Getenv(environmentName) {
+func (e *Env) GetBoolEnvironmentDefaultFalse(environmentName string) bool {
+	switch e.getenv(environmentName) {
 	case "1", "true", "yes":
 		return true
 	default:
@@ -43,8 +137,8 @@ func GetBoolEnvironmentDefaultFalse(environmentName string) bool {
 	}
 }
 
-func GetBoolEnvironmentDefaultTrue(environmentName string) bool {
-	switch os.Getenv(environmentName) {
+func (e *Env) GetBoolEnvironmentDefaultTrue(environmentName string) bool {
+	switch e.getenv(environmentName) {
 	case "0", "false", "no":
 		return false
 	default:
@@ -52,9 +146,9 @@ func GetBoolEnvironmentDefaultTrue(environmentName string) bool {
 	}
 }
 
-func GetFirstExistingEnvVarAsString(envNames ...string) string {
+func (e *Env) GetFirstExistingEnvVarAsString(envNames ...string) string {
 	for _, envName := range envNames {
-		if v := os.Getenv(envName); v != "" {
+		if v := e.getenv(envName); v != "" {
 			return v
 		}
 	}
@@ -62,9 +156,9 @@ func GetFirstExistingEnvVarAsString(envNames ...string) string {
 	return ""
 }
 
-func GetFirstExistingEnvVarAsInt(envNames ...string) (*int, error) {
+func (e *Env) GetFirstExistingEnvVarAsInt(envNames ...string) (*int, error) {
 	for _, envName := range envNames {
-		result, err := GetIntEnvVar(envName)
+		result, err := e.GetIntEnvVar(envName)
 		if err != nil {
 			return nil, err
 		}
@@ -77,10 +171,10 @@ func GetFirstExistingEnvVarAsInt(envNames ...string) (*int, error) {
 	return nil, nil
 }
 
-func PredefinedValuesByEnvNamePrefix(envNamePrefix string, envNamePrefixesToExcept ...string) []string {
+func (e *Env) PredefinedValuesByEnvNamePrefix(envNamePrefix string, envNamePrefixesToExcept ...string) []string {
 	var result []string
 
-	env := os.Environ()
+	env := e.provider.Environ()
 	sort.Strings(env)
 
 environLoop:
@@ -100,8 +194,8 @@ environLoop:
 	return result
 }
 
-func GetInt64EnvVar(varName string) (*int64, error) {
-	if v := os.Getenv(varName); v != "" {
+func (e *Env) GetInt64EnvVar(varName string) (*int64, error) {
+	if v := e.getenv(varName); v != "" {
 		vInt, err := strconv.ParseInt(v, 10, 64)
 		if err != nil {
 			return nil, fmt.Errorf("bad %s variable value %q: %w", varName, v, err)
@@ -116,8 +210,8 @@ func GetInt64EnvVar(varName string) (*int64, error) {
 	return nil, nil
 }
 
-func GetIntEnvVar(varName string) (*int64, error) {
-	if v := os.Getenv(varName); v != "" {
+func (e *Env) GetIntEnvVar(varName string) (*int64, error) {
+	if v := e.getenv(varName); v != "" {
 		vInt, err := strconv.ParseInt(v, 10, 64)
 		if err != nil {
 			return nil, fmt.Errorf("bad %s variable value %q: %w", varName, v, err)
@@ -132,8 +226,8 @@ func GetIntEnvVar(varName string) (*int64, error) {
 	return nil, nil
 }
 
-func GetIntEnvVarDefault(varName string, defaultValue int) (int, error) {
-	val, err := GetIntEnvVar(varName)
+func (e *Env) GetIntEnvVarDefault(varName string, defaultValue int) (int, error) {
+	val, err := e.GetIntEnvVar(varName)
 	if err != nil {
 		return 0, err
 	}
@@ -145,8 +239,8 @@ func GetIntEnvVarDefault(varName string, defaultValue int) (int, error) {
 	return int(*val), nil
 }
 
-func GetUint64EnvVar(varName string) (*uint64, error) {
-	if v := os.Getenv(varName); v != "" {
+func (e *Env) GetUint64EnvVar(varName string) (*uint64, error) {
+	if v := e.getenv(varName); v != "" {
 		vUint, err := strconv.ParseUint(v, 10, 64)
 		if err != nil {
 			return nil, fmt.Errorf("bad %s variable value %q: %w", varName, v, err)
@@ -161,30 +255,36 @@ func GetUint64EnvVar(varName string) (*uint64, error) {
 	return nil, nil
 }
 
-func GetIntEnvVarStrict(varName string) *int64 {
-	valP, err := GetIntEnvVar(varName)
+func (e *Env) GetIntEnvVarStrict(varName string) *int64 {
+	valP, err := e.GetIntEnvVar(varName)
 	if err != nil {
 		panic(fmt.Sprintf("bad %s value: %s", varName, err))
 	}
 	return valP
 }
 
-func GetUint64EnvVarStrict(varName string) *uint64 {
-	valP, err := GetUint64EnvVar(varName)
+func (e *Env) GetUint64EnvVarStrict(varName string) *uint64 {
+	valP, err := e.GetUint64EnvVar(varName)
 	if err != nil {
 		panic(fmt.Sprintf("bad %s value: %s", varName, err))
 	}
 	return valP
 }
 
-func GetStringToStringEnvVar(varName string) (map[string]string, error) {
+func (e *Env) GetStringToStringEnvVar(varName string) (map[string]string, error) {
 	result := map[string]string{}
 
-	val := os.Getenv(varName)
+	val := e.getenv(varName)
 	if val == "" {
 		return result, nil
 	}
 
+	return parseStringToStringEnvValue(val)
+}
+
+func parseStringToStringEnvValue(val string) (map[string]string, error) {
+	result := map[string]string{}
+
 	var ss []string
 	n := strings.Count(val, "=")
 	switch n {
@@ -212,8 +312,8 @@ func GetStringToStringEnvVar(varName string) (map[string]string, error) {
 	return result, nil
 }
 
-func GetDurationEnvVar(varName string) (time.Duration, error) {
-	if v := os.Getenv(varName); v != "" {
+func (e *Env) GetDurationEnvVar(varName string) (time.Duration, error) {
+	if v := e.getenv(varName); v != "" {
 		vDuration, err := time.ParseDuration(v)
 		if err != nil {
 			return 0, fmt.Errorf("bad %s variable value %q: %w", varName, v, err)
@@ -224,3 +324,46 @@ func GetDurationEnvVar(varName string) (time.Duration, error) {
 
 	return 0, nil
 }
+
+// GetByteSizeEnvVar parses the variable with ParseByteSize. Returns nil if the variable is empty.
+func (e *Env) GetByteSizeEnvVar(varName string) (*int64, error) {
+	if v := e.getenv(varName); v != "" {
+		size, err := ParseByteSize(v)
+		if err != nil {
+			return nil, fmt.Errorf("bad %s variable value %q: %w", varName, v, err)
+		}
+
+		return &size, nil
+	}
+
+	return nil, nil
+}
+
+// GetPercentageEnvVar parses the variable with ParsePercentage. Returns nil if the variable is
+// empty.
+func (e *Env) GetPercentageEnvVar(varName string) (*float64, error) {
+	if v := e.getenv(varName); v != "" {
+		percentage, err := ParsePercentage(v)
+		if err != nil {
+			return nil, fmt.Errorf("bad %s variable value %q: %w", varName, v, err)
+		}
+
+		return &percentage, nil
+	}
+
+	return nil, nil
+}
+
+// GetIntListEnvVar parses the variable with ParseIntList. Returns nil if the variable is empty.
+func (e *Env) GetIntListEnvVar(varName string) ([]int, error) {
+	if v := e.getenv(varName); v != "" {
+		list, err := ParseIntList(v)
+		if err != nil {
+			return nil, fmt.Errorf("bad %s variable value %q: %w", varName, v, err)
+		}
+
+		return list, nil
+	}
+
+	return nil, nil
+}
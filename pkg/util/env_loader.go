@@ -0,0 +1,230 @@
+package util
+
+import (
+	"encoding"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	durationType        = reflect.TypeOf(time.Duration(0))
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+type LoadEnvOptions struct {
+	// Prepended to the env var names of all fields, e.g. "WERF_".
+	Prefix string
+}
+
+// LoadEnv is Env.Load for the OS environment.
+func LoadEnv(dest any, opts LoadEnvOptions) error {
+	return osEnv.Load(dest, opts)
+}
+
+// Load fills the struct pointed to by dest from env vars according to the field tags:
+//
+//	env:"NAME"         the env var name, the prefix is prepended
+//	default:"10"       the value used if the env var is not set or empty
+//	required:"true"    the env var must be set to a non-empty value, the default is ignored
+//	format:"byteSize"  parse an int field with ParseByteSize, or "percentage" for a float field
+//	                   with ParsePercentage
+//	envPrefix:"DB_"    for a struct or a pointer to struct field without the env tag: appended to
+//	                   the prefix for the fields of the nested struct, nil pointers are allocated
+//	                   (struct fields are loaded even without the tag, pointers are not)
+//
+// Supported field types are string, bool, ints, uints, floats, time.Duration, []string
+// (comma-separated), []int (see ParseIntList), map[string]string (see GetStringToStringEnvVar),
+// encoding.TextUnmarshaler and pointers to them, which are left nil if there is no value. Fields without a value are left untouched. Bool values are the same as for
+// LookupBoolEnvironment ("1", "true", "yes", "0", "false", "no"), but unlike it and
+// GetBoolEnvironmentDefaultFalse, any other value is an error rather than ignored or false. All
+// errors are returned, joined.
+func (e *Env) Load(dest any, opts LoadEnvOptions) error {
+	value := reflect.ValueOf(dest)
+	if value.Kind() != reflect.Pointer || value.IsNil() || value.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("expected a non-nil pointer to a struct, got %T", dest)
+	}
+
+	return errors.Join(e.loadStruct(value.Elem(), opts.Prefix)...)
+}
+
+func (e *Env) loadStruct(structValue reflect.Value, prefix string) []error {
+	var errs []error
+
+	structType := structValue.Type()
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, ok := field.Tag.Lookup("env")
+		if !ok {
+			_, hasEnvPrefix := field.Tag.Lookup("envPrefix")
+
+			switch {
+			case field.Type.Kind() == reflect.Struct:
+				errs = append(errs, e.loadStruct(structValue.Field(i), prefix+field.Tag.Get("envPrefix"))...)
+			// Pointers are allocated only for explicitly nested structs.
+			case field.Type.Kind() == reflect.Pointer && field.Type.Elem().Kind() == reflect.Struct && hasEnvPrefix:
+				fieldValue := structValue.Field(i)
+				if fieldValue.IsNil() {
+					fieldValue.Set(reflect.New(field.Type.Elem()))
+				}
+
+				errs = append(errs, e.loadStruct(fieldValue.Elem(), prefix+field.Tag.Get("envPrefix"))...)
+			}
+
+			continue
+		}
+		name = prefix + name
+
+		if value := e.getenv(name); value != "" {
+			if err := setEnvFieldValue(structValue.Field(i), value, field.Tag.Get("format")); err != nil {
+				errs = append(errs, fmt.Errorf("bad %s variable value %q: %w", name, value, err))
+			}
+		} else if field.Tag.Get("required") == "true" {
+			errs = append(errs, fmt.Errorf("%s variable is required", name))
+		} else if defaultValue := field.Tag.Get("default"); defaultValue != "" {
+			if err := setEnvFieldValue(structValue.Field(i), defaultValue, field.Tag.Get("format")); err != nil {
+				errs = append(errs, fmt.Errorf("bad %s variable default value %q: %w", name, defaultValue, err))
+			}
+		}
+	}
+
+	return errs
+}
+
+func setEnvFieldValue(field reflect.Value, value, format string) error {
+	if field.Kind() == reflect.Pointer {
+		elem := reflect.New(field.Type().Elem())
+		if err := setEnvFieldValue(elem.Elem(), value, format); err != nil {
+			return err
+		}
+
+		field.Set(elem)
+
+		return nil
+	}
+
+	switch format {
+	case "":
+	case "byteSize":
+		if !field.CanInt() {
+			return fmt.Errorf("format %q is not supported for type %s", format, field.Type())
+		}
+
+		size, err := ParseByteSize(value)
+		if err != nil {
+			return err
+		}
+		if field.OverflowInt(size) {
+			return fmt.Errorf("byte size %q is out of range of %s", value, field.Type())
+		}
+
+		field.SetInt(size)
+
+		return nil
+	case "percentage":
+		if !field.CanFloat() {
+			return fmt.Errorf("format %q is not supported for type %s", format, field.Type())
+		}
+
+		percentage, err := ParsePercentage(value)
+		if err != nil {
+			return err
+		}
+
+		field.SetFloat(percentage)
+
+		return nil
+	default:
+		return fmt.Errorf("unknown format %q", format)
+	}
+
+	if field.Addr().Type().Implements(textUnmarshalerType) {
+		return field.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(value))
+	}
+
+	if field.Type() == durationType {
+		duration, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+
+		field.SetInt(int64(duration))
+
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		b, ok := parseBoolEnvValue(value)
+		if !ok {
+			return fmt.Errorf(`expected one of "1", "true", "yes", "0", "false", "no"`)
+		}
+
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		field.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		field.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		field.SetFloat(f)
+	case reflect.Slice:
+		switch field.Type().Elem().Kind() {
+		case reflect.String:
+			list, err := csv.NewReader(strings.NewReader(value)).Read()
+			if err != nil {
+				return fmt.Errorf("read comma-separated values: %w", err)
+			}
+
+			field.Set(reflect.ValueOf(list).Convert(field.Type()))
+		case reflect.Int:
+			list, err := ParseIntList(value)
+			if err != nil {
+				return err
+			}
+
+			field.Set(reflect.ValueOf(list).Convert(field.Type()))
+		default:
+			return fmt.Errorf("unsupported type %s", field.Type())
+		}
+	case reflect.Map:
+		if field.Type().Key().Kind() != reflect.String || field.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported type %s", field.Type())
+		}
+
+		m, err := parseStringToStringEnvValue(value)
+		if err != nil {
+			return err
+		}
+
+		field.Set(reflect.ValueOf(m).Convert(field.Type()))
+	default:
+		return fmt.Errorf("unsupported type %s", field.Type())
+	}
+
+	return nil
+}
@@ -0,0 +1,98 @@
+package util
+
+import (
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testDatabaseEnvConfig struct {
+	Host string `env:"HOST" default:"localhost"`
+	Port int    `env:"PORT" default:"5432"`
+}
+
+type testEnvConfig struct {
+	Dir       string                 `env:"DIR" required:"true"`
+	Debug     bool                   `env:"DEBUG"`
+	Workers   *int                   `env:"WORKERS"`
+	Timeout   time.Duration          `env:"TIMEOUT" default:"1m"`
+	CacheSize int64                  `env:"CACHE_SIZE" format:"byteSize" default:"1Gi"`
+	Ratio     float64                `env:"RATIO" format:"percentage"`
+	Ports     []int                  `env:"PORTS"`
+	Platforms []string               `env:"PLATFORMS"`
+	Labels    map[string]string      `env:"LABELS"`
+	IP        net.IP                 `env:"IP"`
+	Database  testDatabaseEnvConfig  `envPrefix:"DB_"`
+	Replica   *testDatabaseEnvConfig `envPrefix:"REPLICA_"`
+	Ignored   string
+}
+
+func TestEnvLoad(t *testing.T) {
+	env := NewEnv(MapEnvProvider{
+		"WERF_DIR":          "/work",
+		"WERF_DEBUG":        "yes",
+		"WERF_RATIO":        "25%",
+		"WERF_PORTS":        "80,443",
+		"WERF_PLATFORMS":    "linux/amd64,linux/arm64",
+		"WERF_LABELS":       "a=1,b=2",
+		"WERF_IP":           "10.0.0.1",
+		"WERF_DB_HOST":      "db",
+		"WERF_REPLICA_HOST": "replica",
+		"IGNORED":           "x",
+	})
+
+	var config testEnvConfig
+	if err := env.Load(&config, LoadEnvOptions{Prefix: "WERF_"}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := testEnvConfig{
+		Dir:       "/work",
+		Debug:     true,
+		Timeout:   time.Minute,
+		CacheSize: 1 << 30,
+		Ratio:     25,
+		Ports:     []int{80, 443},
+		Platforms: []string{"linux/amd64", "linux/arm64"},
+		Labels:    map[string]string{"a": "1", "b": "2"},
+		IP:        net.ParseIP("10.0.0.1"),
+		Database:  testDatabaseEnvConfig{Host: "db", Port: 5432},
+		Replica:   &testDatabaseEnvConfig{Host: "replica", Port: 5432},
+	}
+	if !reflect.DeepEqual(config, want) {
+		t.Errorf("Load() = %+v, want %+v", config, want)
+	}
+}
+
+func TestEnvLoadErrors(t *testing.T) {
+	env := NewEnv(MapEnvProvider{
+		"WERF_DEBUG":   "maybe",
+		"WERF_WORKERS": "many",
+		"WERF_RATIO":   "150",
+		"WERF_DB_PORT": "99999999999999999999",
+	})
+
+	var config testEnvConfig
+	err := env.Load(&config, LoadEnvOptions{Prefix: "WERF_"})
+	if err == nil {
+		t.Fatal("Load() expected error")
+	}
+
+	for _, want := range []string{
+		"WERF_DIR variable is required",
+		`bad WERF_DEBUG variable value "maybe"`,
+		`bad WERF_WORKERS variable value "many"`,
+		`bad WERF_RATIO variable value "150": invalid percentage: must be from 0 to 100`,
+		`bad WERF_DB_PORT variable value "99999999999999999999"`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Load() error = %q, want it to contain %q", err, want)
+		}
+	}
+
+	if err := env.Load(config, LoadEnvOptions{}); err == nil {
+		t.Errorf("Load() expected error for a non-pointer")
+	}
+}
@@ -0,0 +1,156 @@
+package util
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	_ EnvProvider = OSEnvProvider{}
+	_ EnvProvider = MapEnvProvider{}
+)
+
+var dotenvNameRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]*$`)
+
+// EnvProvider is a source of environment variables.
+type EnvProvider interface {
+	LookupEnv(name string) (string, bool)
+	// Environ returns all variables in the form "KEY=value".
+	Environ() []string
+}
+
+// OSEnvProvider reads the environment of the current process.
+type OSEnvProvider struct{}
+
+func (OSEnvProvider) LookupEnv(name string) (string, bool) {
+	return os.LookupEnv(name)
+}
+
+func (OSEnvProvider) Environ() []string {
+	return os.Environ()
+}
+
+// MapEnvProvider reads variables from the map, e.g. a recorded CI environment.
+type MapEnvProvider map[string]string
+
+func (p MapEnvProvider) LookupEnv(name string) (string, bool) {
+	value, ok := p[name]
+	return value, ok
+}
+
+func (p MapEnvProvider) Environ() []string {
+	environ := make([]string, 0, len(p))
+	for name, value := range p {
+		environ = append(environ, name+"="+value)
+	}
+	sort.Strings(environ)
+
+	return environ
+}
+
+// NewDotenvEnvProvider reads variables from the .env file, see ParseDotenv.
+func NewDotenvEnvProvider(path string) (MapEnvProvider, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open %q: %w", path, err)
+	}
+	defer file.Close()
+
+	vars, err := ParseDotenv(file)
+	if err != nil {
+		return nil, fmt.Errorf("parse %q: %w", path, err)
+	}
+
+	return vars, nil
+}
+
+// ParseDotenv parses "KEY=value" lines. Empty lines and lines starting with "#" are skipped, the
+// "export " prefix is allowed. Names consist of letters, digits, "_", "." and "-" and don't
+// start with a digit. Values might be in single quotes (taken literally) or in double
+// quotes (with \n, \t, \" and \\ escapes). Unquoted values are trimmed and might be followed by
+// a " #" comment. Variables are not expanded.
+func ParseDotenv(in io.Reader) (MapEnvProvider, error) {
+	vars := MapEnvProvider{}
+
+	scanner := bufio.NewScanner(in)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		line = strings.TrimPrefix(line, "export ")
+
+		name, value, found := strings.Cut(line, "=")
+		name = strings.TrimSpace(name)
+		if !found {
+			return nil, fmt.Errorf("line %d: expected KEY=value", lineNum)
+		}
+
+		if !dotenvNameRegex.MatchString(name) {
+			return nil, fmt.Errorf("line %d: bad variable name %q", lineNum, name)
+		}
+
+		value, err := parseDotenvValue(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: variable %q: %w", lineNum, name, err)
+		}
+
+		vars[name] = value
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read: %w", err)
+	}
+
+	return vars, nil
+}
+
+func parseDotenvValue(value string) (string, error) {
+	switch {
+	case strings.HasPrefix(value, "'"):
+		end := strings.Index(value[1:], "'")
+		if end == -1 {
+			return "", fmt.Errorf("unterminated single-quoted value")
+		}
+
+		return value[1 : end+1], nil
+	case strings.HasPrefix(value, `"`):
+		var b strings.Builder
+		for i := 1; i < len(value); i++ {
+			switch value[i] {
+			case '"':
+				return b.String(), nil
+			case '\\':
+				if i+1 == len(value) {
+					return "", fmt.Errorf("unterminated double-quoted value")
+				}
+
+				i++
+				switch value[i] {
+				case 'n':
+					b.WriteByte('\n')
+				case 't':
+					b.WriteByte('\t')
+				default:
+					b.WriteByte(value[i])
+				}
+			default:
+				b.WriteByte(value[i])
+			}
+		}
+
+		return "", fmt.Errorf("unterminated double-quoted value")
+	default:
+		if ind := strings.Index(value, " #"); ind != -1 {
+			value = value[:ind]
+		}
+
+		return strings.TrimSpace(value), nil
+	}
+}
@@ -0,0 +1,121 @@
+package util
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseDotenv(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    MapEnvProvider
+		wantErr string
+	}{
+		{
+			name: "plain",
+			in: `
+# comment
+A=1
+export B = two words  # comment
+C=
+D=x=y
+`,
+			want: MapEnvProvider{"A": "1", "B": "two words", "C": "", "D": "x=y"},
+		},
+		{
+			name: "quoted",
+			in:   `S='literal \n # kept'` + "\n" + `D="line1\nline2 \"quoted\" \\"`,
+			want: MapEnvProvider{"S": `literal \n # kept`, "D": "line1\nline2 \"quoted\" \\"},
+		},
+		{name: "no equals sign", in: "A=1\nB\n", wantErr: "line 2: expected KEY=value"},
+		{name: "bad name", in: "A=1\nnot a name=2\n", wantErr: `line 2: bad variable name "not a name"`},
+		{name: "unterminated quote", in: `A="x`, wantErr: `line 1: variable "A": unterminated double-quoted value`},
+		{name: "unterminated single quote", in: `A='x`, wantErr: `line 1: variable "A": unterminated single-quoted value`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseDotenv(strings.NewReader(tt.in))
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("ParseDotenv() error = %v, wantErr %q", err, tt.wantErr)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("ParseDotenv() error = %v", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseDotenv() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnvWithDotenvProvider(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(path, []byte("WORKERS=4\nTIMEOUT=1m\nDEBUG=yes\nLABELS=a=1,b=2\nPREFIX_A=x\nPREFIX_B=y\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	provider, err := NewDotenvEnvProvider(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	env := NewEnv(provider)
+
+	if workers, err := env.GetIntEnvVarDefault("WORKERS", 1); err != nil || workers != 4 {
+		t.Errorf("GetIntEnvVarDefault() = %v, %v", workers, err)
+	}
+
+	if timeout, err := env.GetDurationEnvVar("TIMEOUT"); err != nil || timeout != time.Minute {
+		t.Errorf("GetDurationEnvVar() = %v, %v", timeout, err)
+	}
+
+	if !env.GetBoolEnvironmentDefaultFalse("DEBUG") || !env.GetBoolEnvironmentDefaultTrue("MISSING") {
+		t.Errorf("GetBoolEnvironmentDefault*() returned unexpected values")
+	}
+
+	if labels, err := env.GetStringToStringEnvVar("LABELS"); err != nil || !reflect.DeepEqual(labels, map[string]string{"a": "1", "b": "2"}) {
+		t.Errorf("GetStringToStringEnvVar() = %v, %v", labels, err)
+	}
+
+	if values := env.PredefinedValuesByEnvNamePrefix("PREFIX_", "PREFIX_B"); !reflect.DeepEqual(values, []string{"x"}) {
+		t.Errorf("PredefinedValuesByEnvNamePrefix() = %v", values)
+	}
+
+	if _, err := NewDotenvEnvProvider(path + ".missing"); err == nil {
+		t.Errorf("NewDotenvEnvProvider() expected error for missing file")
+	}
+}
+
+func TestEnvUnitValues(t *testing.T) {
+	env := NewEnv(MapEnvProvider{"CACHE_SIZE": "10Gi", "MAX_UNAVAILABLE": "25%", "PORTS": "80,443", "BAD": "10 parsecs"})
+
+	if size, err := env.GetByteSizeEnvVar("CACHE_SIZE"); err != nil || size == nil || *size != 10<<30 {
+		t.Errorf("GetByteSizeEnvVar() = %v, %v", size, err)
+	}
+
+	if percentage, err := env.GetPercentageEnvVar("MAX_UNAVAILABLE"); err != nil || percentage == nil || *percentage != 25 {
+		t.Errorf("GetPercentageEnvVar() = %v, %v", percentage, err)
+	}
+
+	if ports, err := env.GetIntListEnvVar("PORTS"); err != nil || !reflect.DeepEqual(ports, []int{80, 443}) {
+		t.Errorf("GetIntListEnvVar() = %v, %v", ports, err)
+	}
+
+	if size, err := env.GetByteSizeEnvVar("MISSING"); err != nil || size != nil {
+		t.Errorf("GetByteSizeEnvVar() = %v, %v, want nil for a missing variable", size, err)
+	}
+
+	_, err := env.GetPercentageEnvVar("BAD")
+	if want := `bad BAD variable value "10 parsecs": invalid percentage: not a number`; err == nil || err.Error() != want {
+		t.Errorf("GetPercentageEnvVar() error = %v, want %q", err, want)
+	}
+}
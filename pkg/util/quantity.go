@@ -0,0 +1,194 @@
+package util
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var (
+	byteSizeUnits = map[string]float64{
+		"":    1,
+		"B":   1,
+		"k":   1e3,
+		"K":   1e3,
+		"kB":  1e3,
+		"KB":  1e3,
+		"M":   1e6,
+		"MB":  1e6,
+		"G":   1e9,
+		"GB":  1e9,
+		"T":   1e12,
+		"TB":  1e12,
+		"P":   1e15,
+		"PB":  1e15,
+		"E":   1e18,
+		"EB":  1e18,
+		"Ki":  1 << 10,
+		"KiB": 1 << 10,
+		"Mi":  1 << 20,
+		"MiB": 1 << 20,
+		"Gi":  1 << 30,
+		"GiB": 1 << 30,
+		"Ti":  1 << 40,
+		"TiB": 1 << 40,
+		"Pi":  1 << 50,
+		"PiB": 1 << 50,
+		"Ei":  1 << 60,
+		"EiB": 1 << 60,
+	}
+
+	quantityUnits = map[string]float64{
+		"":   1,
+		"m":  1e-3,
+		"k":  1e3,
+		"M":  1e6,
+		"G":  1e9,
+		"T":  1e12,
+		"P":  1e15,
+		"E":  1e18,
+		"Ki": 1 << 10,
+		"Mi": 1 << 20,
+		"Gi": 1 << 30,
+		"Ti": 1 << 40,
+		"Pi": 1 << 50,
+		"Ei": 1 << 60,
+	}
+
+	formatByteSizeUnits = []struct {
+		suffix string
+		size   int64
+	}{
+		{"Ei", 1 << 60},
+		{"Pi", 1 << 50},
+		{"Ti", 1 << 40},
+		{"Gi", 1 << 30},
+		{"Mi", 1 << 20},
+		{"Ki", 1 << 10},
+	}
+)
+
+// ParseByteSize parses a size in bytes with an optional decimal ("500MB", "1.5G") or binary
+// ("512Mi", "10GiB") unit suffix. The size must be a whole number of bytes, e.g. "1.5B" or
+// "0.1Ki" are errors.
+func ParseByteSize(s string) (int64, error) {
+	number, multiplier, err := splitNumberAndUnit(s, byteSizeUnits)
+	if err != nil {
+		return 0, fmt.Errorf("invalid byte size: %w", err)
+	}
+
+	exactSize := number * multiplier
+	size := math.Round(exactSize)
+	// Allow for float errors, e.g. 1.1 * 1e6 is 1100000.0000000002.
+	if math.Abs(exactSize-size) > math.Max(math.Abs(size), 1)*1e-9 {
+		return 0, fmt.Errorf("invalid byte size: not a whole number of bytes")
+	}
+	if size < 0 {
+		return 0, fmt.Errorf("invalid byte size: must not be negative")
+	}
+	if size >= math.MaxInt64 {
+		return 0, fmt.Errorf("invalid byte size: too large")
+	}
+
+	return int64(size), nil
+}
+
+// FormatByteSize formats a size in bytes using the largest binary unit which divides it
+// without remainder, e.g. "512Mi". The result can be parsed with ParseByteSize.
+func FormatByteSize(size int64) string {
+	if size != 0 {
+		for _, unit := range formatByteSizeUnits {
+			if size%unit.size == 0 {
+				return fmt.Sprintf("%d%s", size/unit.size, unit.suffix)
+			}
+		}
+	}
+
+	return strconv.FormatInt(size, 10)
+}
+
+// ParseQuantity parses a resource quantity in the Kubernetes notation: a number with an optional
+// decimal ("500m" = 0.5, "2k") or binary ("1.5Gi") unit suffix.
+func ParseQuantity(s string) (float64, error) {
+	number, multiplier, err := splitNumberAndUnit(s, quantityUnits)
+	if err != nil {
+		return 0, fmt.Errorf("invalid quantity: %w", err)
+	}
+
+	return number * multiplier, nil
+}
+
+func splitNumberAndUnit(s string, units map[string]float64) (float64, float64, error) {
+	s = strings.TrimSpace(s)
+
+	unitStart := len(s)
+	for unitStart > 0 && isUnitChar(s[unitStart-1]) {
+		unitStart--
+	}
+	numberStr, unit := s[:unitStart], s[unitStart:]
+
+	multiplier, ok := units[unit]
+	if !ok {
+		return 0, 0, fmt.Errorf("unknown unit %q", unit)
+	}
+
+	if numberStr == "" {
+		return 0, 0, fmt.Errorf("number expected")
+	}
+
+	number, err := strconv.ParseFloat(numberStr, 64)
+	if err != nil || math.IsNaN(number) || math.IsInf(number, 0) {
+		return 0, 0, fmt.Errorf("bad number %q", numberStr)
+	}
+
+	return number, multiplier, nil
+}
+
+func isUnitChar(c byte) bool {
+	// "e" and "E" are not unit chars when they are part of the exponent, e.g. "1e3".
+	return (c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z') && c != 'e'
+}
+
+// ParsePercentage parses a percentage from 0 to 100 with an optional "%" suffix, e.g. "25%",
+// "12.5" or "100%".
+func ParsePercentage(s string) (float64, error) {
+	numberStr := strings.TrimSuffix(strings.TrimSpace(s), "%")
+
+	percentage, err := strconv.ParseFloat(strings.TrimSpace(numberStr), 64)
+	if err != nil || math.IsNaN(percentage) || math.IsInf(percentage, 0) {
+		return 0, fmt.Errorf("invalid percentage: not a number")
+	}
+
+	if percentage < 0 || percentage > 100 {
+		return 0, fmt.Errorf("invalid percentage: must be from 0 to 100")
+	}
+
+	return percentage, nil
+}
+
+// FormatPercentage formats a percentage with the "%" suffix, e.g. "12.5%". The result can be
+// parsed with ParsePercentage.
+func FormatPercentage(percentage float64) string {
+	return strconv.FormatFloat(percentage, 'f', -1, 64) + "%"
+}
+
+// ParseIntList parses a comma-separated list of integers, e.g. "80, 443". An empty string is an
+// empty list, empty elements are not allowed.
+func ParseIntList(s string) ([]int, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+
+	var result []int
+	for i, element := range strings.Split(s, ",") {
+		number, err := strconv.Atoi(strings.TrimSpace(element))
+		if err != nil {
+			return nil, fmt.Errorf("invalid int list: bad element %d %q", i+1, strings.TrimSpace(element))
+		}
+
+		result = append(result, number)
+	}
+
+	return result, nil
+}
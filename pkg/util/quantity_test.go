@@ -0,0 +1,142 @@
+package util
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseByteSize(t *testing.T) {
+	tests := []struct {
+		arg     string
+		want    int64
+		wantErr bool
+	}{
+		{arg: "1024", want: 1024},
+		{arg: "100B", want: 100},
+		{arg: "500MB", want: 500_000_000},
+		{arg: "1.5G", want: 1_500_000_000},
+		{arg: "512Mi", want: 512 << 20},
+		{arg: "10GiB", want: 10 << 30},
+		{arg: "1e3", want: 1000},
+		{arg: "1.1MB", want: 1_100_000},
+		{arg: "0.5Ki", want: 512},
+		{arg: "", wantErr: true},
+		{arg: "Mi", wantErr: true},
+		{arg: "10m", wantErr: true},
+		{arg: "-1Ki", wantErr: true},
+		{arg: "100Ei", wantErr: true},
+		{arg: "1.5B", wantErr: true},
+		{arg: "0.1Ki", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.arg, func(t *testing.T) {
+			got, err := ParseByteSize(tt.arg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseByteSize() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseByteSize() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatByteSize(t *testing.T) {
+	tests := []struct {
+		arg  int64
+		want string
+	}{
+		{arg: 0, want: "0"},
+		{arg: 1000, want: "1000"},
+		{arg: 3072, want: "3Ki"},
+		{arg: 512 << 20, want: "512Mi"},
+		{arg: 1 << 30, want: "1Gi"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := FormatByteSize(tt.arg); got != tt.want {
+				t.Errorf("FormatByteSize() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseQuantity(t *testing.T) {
+	tests := []struct {
+		arg     string
+		want    float64
+		wantErr bool
+	}{
+		{arg: "2", want: 2},
+		{arg: "500m", want: 0.5},
+		{arg: "2k", want: 2000},
+		{arg: "1.5Gi", want: 1.5 * (1 << 30)},
+		{arg: "1KB", wantErr: true},
+		{arg: "abc", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.arg, func(t *testing.T) {
+			got, err := ParseQuantity(tt.arg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseQuantity() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseQuantity() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePercentage(t *testing.T) {
+	tests := []struct {
+		arg     string
+		want    float64
+		wantErr bool
+	}{
+		{arg: "25%", want: 25},
+		{arg: "12.5", want: 12.5},
+		{arg: " 100 % ", want: 100},
+		{arg: "0%", want: 0},
+		{arg: "", wantErr: true},
+		{arg: "%", wantErr: true},
+		{arg: "101%", wantErr: true},
+		{arg: "-1%", wantErr: true},
+		{arg: "half", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.arg, func(t *testing.T) {
+			got, err := ParsePercentage(tt.arg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParsePercentage() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParsePercentage() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseIntList(t *testing.T) {
+	tests := []struct {
+		arg     string
+		want    []int
+		wantErr bool
+	}{
+		{arg: "", want: nil},
+		{arg: "80", want: []int{80}},
+		{arg: "80, 443,-1", want: []int{80, 443, -1}},
+		{arg: "80,,443", wantErr: true},
+		{arg: "80,http", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.arg, func(t *testing.T) {
+			got, err := ParseIntList(tt.arg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseIntList() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseIntList() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
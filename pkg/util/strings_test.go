@@ -23,3 +23,25 @@ func TestReverse(t *testing.T) {
 		})
 	}
 }
+
+func TestLevenshteinDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"", "abc", 3},
+		{"abc", "", 3},
+		{"kitten", "sitting", 3},
+		{"NELM_RELEASE_NAME", "NELM_RELEASE_NAME", 0},
+		{"NELM_RELAESE_NAME", "NELM_RELEASE_NAME", 2},
+		{"世界", "世", 1},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%q/%q", tt.a, tt.b), func(t *testing.T) {
+			if got := LevenshteinDistance(tt.a, tt.b); got != tt.want {
+				t.Errorf("LevenshteinDistance() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}